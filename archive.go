@@ -0,0 +1,370 @@
+package gl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spacemonkeygo/errors"
+)
+
+type ArchiveFormat string
+
+const (
+	TarGz  = ArchiveFormat("tar.gz")
+	TarBz2 = ArchiveFormat("tar.bz2")
+	Tar    = ArchiveFormat("tar")
+	Zip    = ArchiveFormat("zip")
+)
+
+const (
+	archiveformat_url = "/projects/:id/repository/archive.:format"
+)
+
+var archiveError = errors.NewClass("archive")
+
+// ArchiveOptions contains the optional parameters of an archive download.
+// Only the non-nil values are sent to gitlab, the default format is
+// TarGz.
+type ArchiveOptions struct {
+	Sha    *string
+	Path   *string
+	Format *ArchiveFormat
+}
+
+func (o *ArchiveOptions) values() url.Values {
+	vals := make(url.Values)
+	if o == nil {
+		return vals
+	}
+	addString(vals, "sha", o.Sha)
+	addString(vals, "path", o.Path)
+	return vals
+}
+
+func (o *ArchiveOptions) format() ArchiveFormat {
+	if o == nil || o.Format == nil {
+		return TarGz
+	}
+	return *o.Format
+}
+
+// Returns the archive of the repository, the caller has to close the
+// returned reader.
+func (g *Client) StreamArchive(id string, opts *ArchiveOptions) (io.ReadCloser, error) {
+	u := expandUrl(archiveformat_url, map[string]interface{}{":id": id, ":format": opts.format()})
+	return g.stream(u, opts.values())
+}
+
+// Downloads the archive of the repository and extracts it into dir, see
+// ExtractArchive.
+func (g *Client) DownloadArchive(id string, opts *ArchiveOptions, dir string) error {
+	rc, e := g.StreamArchive(id, opts)
+	if e != nil {
+		return e
+	}
+	defer rc.Close()
+	return ExtractArchive(rc, opts.format(), dir)
+}
+
+// Extracts the archive into dir. The top level folder which gitlab puts
+// around the content of the repository is stripped. Entries which would
+// be written outside of dir, symlinks which resolve to a path outside of
+// dir, also through other links, and entries which would be written
+// through a symlink are rejected.
+func ExtractArchive(r io.Reader, format ArchiveFormat, dir string) error {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return archiveError.Wrap(e)
+	}
+	if e := os.MkdirAll(dir, 0755); e != nil {
+		return archiveError.Wrap(e)
+	}
+	switch format {
+	case TarGz:
+		gz, e := gzip.NewReader(r)
+		if e != nil {
+			return archiveError.Wrap(e)
+		}
+		defer gz.Close()
+		return extractTar(gz, dir)
+	case TarBz2:
+		return extractTar(bzip2.NewReader(r), dir)
+	case Tar:
+		return extractTar(r, dir)
+	case Zip:
+		return extractZip(r, dir)
+	}
+	return InvalidParam.New("unknown archive format: %q", format)
+}
+
+// extractor writes the entries of an archive below dir. Symlinks are
+// created after all other entries and checked against the final tree,
+// so the order of the entries cannot be used to build an escaping chain
+// of links.
+type extractor struct {
+	dir   string
+	links []archiveLink
+}
+
+type archiveLink struct {
+	name, rel, target string
+}
+
+func extractTar(r io.Reader, dir string) error {
+	x := &extractor{dir: dir}
+	tr := tar.NewReader(r)
+	for {
+		hdr, e := tr.Next()
+		if e == io.EOF {
+			return x.finish()
+		}
+		if e != nil {
+			return archiveError.Wrap(e)
+		}
+		var mode os.FileMode
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			mode = os.ModeDir
+		case tar.TypeReg:
+			mode = os.FileMode(hdr.Mode) & os.ModePerm
+		case tar.TypeSymlink:
+			mode = os.ModeSymlink
+		default:
+			// e.g. the pax header with the commit id
+			continue
+		}
+		if e := x.entry(hdr.Name, mode, hdr.Linkname, tr); e != nil {
+			return e
+		}
+	}
+}
+
+// a zip needs random access, so it is buffered in a temporary file
+func extractZip(r io.Reader, dir string) error {
+	tmp, e := ioutil.TempFile("", "gl-archive")
+	if e != nil {
+		return archiveError.Wrap(e)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, e := io.Copy(tmp, r)
+	if e != nil {
+		return archiveError.Wrap(e)
+	}
+	zr, e := zip.NewReader(tmp, size)
+	if e != nil {
+		return archiveError.Wrap(e)
+	}
+	x := &extractor{dir: dir}
+	for _, f := range zr.File {
+		if e := x.zipFile(f); e != nil {
+			return e
+		}
+	}
+	return x.finish()
+}
+
+func (x *extractor) zipFile(f *zip.File) error {
+	rc, e := f.Open()
+	if e != nil {
+		return archiveError.Wrap(e)
+	}
+	defer rc.Close()
+	mode := f.Mode()
+	var target string
+	switch {
+	case mode.IsDir():
+		mode = os.ModeDir
+	case mode&os.ModeSymlink != 0:
+		b, e := ioutil.ReadAll(rc)
+		if e != nil {
+			return archiveError.Wrap(e)
+		}
+		mode, target = os.ModeSymlink, string(b)
+	case mode.IsRegular():
+		mode &= os.ModePerm
+	default:
+		return nil
+	}
+	return x.entry(f.Name, mode, target, rc)
+}
+
+func (x *extractor) entry(name string, mode os.FileMode, linkname string, content io.Reader) error {
+	rel, ok := stripTopLevel(name)
+	if !ok {
+		return archiveError.New("entry %q is outside of the destination", name)
+	}
+	if rel == "" {
+		return nil
+	}
+	if e := checkNoSymlink(x.dir, rel); e != nil {
+		return e
+	}
+	dest := filepath.Join(x.dir, filepath.FromSlash(rel))
+	switch {
+	case mode.IsDir():
+		if e := os.MkdirAll(dest, 0755); e != nil {
+			return archiveError.Wrap(e)
+		}
+		return nil
+	case mode&os.ModeSymlink != 0:
+		if path.IsAbs(linkname) || filepath.IsAbs(linkname) {
+			return archiveError.New("symlink %q points to the absolute path %q", name, linkname)
+		}
+		if _, ok := inside(path.Join(path.Dir(rel), linkname)); !ok {
+			return archiveError.New("symlink %q points outside of the destination", name)
+		}
+		x.links = append(x.links, archiveLink{name: name, rel: rel, target: linkname})
+		return nil
+	}
+	if e := os.MkdirAll(filepath.Dir(dest), 0755); e != nil {
+		return archiveError.Wrap(e)
+	}
+	f, e := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode|0200)
+	if e != nil {
+		return archiveError.Wrap(e)
+	}
+	if _, e := io.Copy(f, content); e != nil {
+		f.Close()
+		return archiveError.Wrap(e)
+	}
+	if e := f.Close(); e != nil {
+		return archiveError.Wrap(e)
+	}
+	return nil
+}
+
+// creates the collected symlinks and checks that every link resolves to
+// a path inside of dir. If one does not, all links are removed again.
+func (x *extractor) finish() error {
+	var created []string
+	remove := func() {
+		for _, c := range created {
+			os.Remove(c)
+		}
+	}
+	for _, l := range x.links {
+		if e := checkNoSymlink(x.dir, l.rel); e != nil {
+			remove()
+			return e
+		}
+		dest := filepath.Join(x.dir, filepath.FromSlash(l.rel))
+		if e := os.MkdirAll(filepath.Dir(dest), 0755); e != nil {
+			remove()
+			return archiveError.Wrap(e)
+		}
+		if e := os.Symlink(l.target, dest); e != nil {
+			remove()
+			return archiveError.Wrap(e)
+		}
+		created = append(created, dest)
+	}
+	for _, l := range x.links {
+		hops := 0
+		if _, ok := resolveInside(x.dir, l.rel, &hops); !ok {
+			remove()
+			return archiveError.New("symlink %q points outside of the destination", l.name)
+		}
+	}
+	return nil
+}
+
+// resolves the slash separated path below dir like the operating system
+// would, following the symlinks on disk, and reports if every step stays
+// inside of dir. Components which do not exist are taken literally. hops
+// counts the followed links of the whole resolution.
+func resolveInside(dir, rel string, hops *int) (string, bool) {
+	var cur []string
+	for _, part := range strings.Split(rel, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(cur) == 0 {
+				return "", false
+			}
+			cur = cur[:len(cur)-1]
+			continue
+		}
+		next := append(cur, part)
+		p := filepath.Join(dir, filepath.FromSlash(strings.Join(next, "/")))
+		fi, e := os.Lstat(p)
+		if e != nil || fi.Mode()&os.ModeSymlink == 0 {
+			cur = next
+			continue
+		}
+		// like the operating system, more links than this are a loop
+		if *hops++; *hops > 40 {
+			return "", false
+		}
+		target, e := os.Readlink(p)
+		if e != nil || path.IsAbs(target) || filepath.IsAbs(target) {
+			return "", false
+		}
+		res, ok := resolveInside(dir, strings.Join(append(cur[:len(cur):len(cur)], target), "/"), hops)
+		if !ok {
+			return "", false
+		}
+		cur = nil
+		if res != "" {
+			cur = strings.Split(res, "/")
+		}
+	}
+	return strings.Join(cur, "/"), true
+}
+
+// strips the first element of the path, the result is empty for the top
+// level folder itself.
+func stripTopLevel(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "./")
+	if pos := strings.Index(name, "/"); pos >= 0 {
+		name = name[pos+1:]
+	} else {
+		name = ""
+	}
+	return inside(name)
+}
+
+// cleans the relative path and reports if it stays inside of its root
+func inside(rel string) (string, bool) {
+	if rel == "" || strings.HasPrefix(rel, "/") || strings.Contains(rel, "\\") {
+		return rel, rel == ""
+	}
+	rel = path.Clean(rel)
+	if rel == "." {
+		return "", true
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
+
+// existing symlinks are not followed, otherwise an archive could first
+// create a link to a directory and then write through it.
+func checkNoSymlink(dir, rel string) error {
+	p := dir
+	for _, part := range strings.Split(rel, "/") {
+		p = filepath.Join(p, part)
+		fi, e := os.Lstat(p)
+		if os.IsNotExist(e) {
+			return nil
+		}
+		if e != nil {
+			return archiveError.Wrap(e)
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return archiveError.New("entry %q would be written through a symlink", rel)
+		}
+	}
+	return nil
+}
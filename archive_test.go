@@ -0,0 +1,177 @@
+package gl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type archiveEntry struct {
+	name, content, link string
+	mode                int64
+	dir                 bool
+}
+
+func makeTarGz(entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: e.mode, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		if e.dir {
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		} else if e.link != "" {
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		}
+		tw.WriteHeader(hdr)
+		if hdr.Typeflag == tar.TypeReg {
+			tw.Write([]byte(e.content))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestArchive(t *testing.T) {
+	Convey("archive test functions", t, func() {
+		tmp, _ := ioutil.TempDir("", "gl-test")
+		defer os.RemoveAll(tmp)
+		Convey("fetch an archive in a given format", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte("zipdata"), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			sha, p, f := "master", "docs", Zip
+			rc, e := cl.StreamArchive("1", &ArchiveOptions{Sha: &sha, Path: &p, Format: &f})
+			So(e, ShouldBeNil)
+			b, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			Convey("check if the request was correct", func() {
+				So(string(b), ShouldEqual, "zipdata")
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/repository/archive.zip")
+				So(h.get("sha"), ShouldEqual, "master")
+				So(h.get("path"), ShouldEqual, "docs")
+			})
+		})
+		Convey("download and extract a tarball", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return makeTarGz(
+					archiveEntry{name: "proj-master-abc/", dir: true},
+					archiveEntry{name: "proj-master-abc/README.md", content: "readme", mode: 0644},
+					archiveEntry{name: "proj-master-abc/bin/run.sh", content: "#!/bin/sh", mode: 0755},
+					archiveEntry{name: "proj-master-abc/run", link: "bin/run.sh"},
+				), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			e := cl.DownloadArchive("1", nil, tmp)
+			Convey("the top level folder must be stripped", func() {
+				So(e, ShouldBeNil)
+				So(h.path, ShouldEqual, "/projects/1/repository/archive.tar.gz")
+				b, _ := ioutil.ReadFile(filepath.Join(tmp, "README.md"))
+				So(string(b), ShouldEqual, "readme")
+				fi, _ := os.Stat(filepath.Join(tmp, "bin", "run.sh"))
+				So(fi.Mode()&0100 != 0, ShouldBeTrue)
+				l, _ := os.Readlink(filepath.Join(tmp, "run"))
+				So(l, ShouldEqual, "bin/run.sh")
+			})
+		})
+		Convey("path traversal is rejected", func() {
+			e := ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/../../evil.txt", content: "x", mode: 0644},
+			)), TarGz, filepath.Join(tmp, "out"))
+			So(archiveError.Contains(e), ShouldBeTrue)
+			_, e = os.Stat(filepath.Join(tmp, "evil.txt"))
+			So(os.IsNotExist(e), ShouldBeTrue)
+		})
+		Convey("symlinks pointing outside are rejected", func() {
+			e := ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/etc", link: "../../etc"},
+			)), TarGz, tmp)
+			So(archiveError.Contains(e), ShouldBeTrue)
+			e = ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/passwd", link: "/etc/passwd"},
+			)), TarGz, tmp)
+			So(archiveError.Contains(e), ShouldBeTrue)
+		})
+		Convey("chains of symlinks escaping the destination are rejected", func() {
+			out := filepath.Join(tmp, "out")
+			e := ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/sub/", dir: true},
+				archiveEntry{name: "top/sub/d", link: ".."},
+				archiveEntry{name: "top/e", link: "sub/d/.."},
+			)), TarGz, out)
+			So(archiveError.Contains(e), ShouldBeTrue)
+			_, e = os.Lstat(filepath.Join(out, "e"))
+			So(os.IsNotExist(e), ShouldBeTrue)
+			e = ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/x", link: "sub2/d"},
+				archiveEntry{name: "top/y", link: "x/.."},
+				archiveEntry{name: "top/sub2/", dir: true},
+				archiveEntry{name: "top/sub2/d", link: ".."},
+			)), TarGz, filepath.Join(tmp, "out2"))
+			So(archiveError.Contains(e), ShouldBeTrue)
+			_, e = os.Lstat(filepath.Join(tmp, "out2", "y"))
+			So(os.IsNotExist(e), ShouldBeTrue)
+		})
+		Convey("the links followed while resolving a symlink are limited", func() {
+			// every link resolves the next one twice
+			entries := []archiveEntry{
+				{name: "top/d/", dir: true},
+				{name: "top/l19", link: "d"},
+			}
+			for i := 18; i >= 0; i-- {
+				entries = append(entries, archiveEntry{name: fmt.Sprintf("top/l%d", i), link: fmt.Sprintf("l%d/../l%d", i+1, i+1)})
+			}
+			start := time.Now()
+			e := ExtractArchive(bytes.NewReader(makeTarGz(entries...)), TarGz, filepath.Join(tmp, "out4"))
+			So(archiveError.Contains(e), ShouldBeTrue)
+			So(time.Since(start) < time.Second, ShouldBeTrue)
+		})
+		Convey("chains of symlinks inside the destination are allowed", func() {
+			out := filepath.Join(tmp, "out3")
+			e := ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/sub/deep/", dir: true},
+				archiveEntry{name: "top/sub/deep/f.txt", content: "f", mode: 0644},
+				archiveEntry{name: "top/sub/d", link: "deep"},
+				archiveEntry{name: "top/e", link: "sub/d/f.txt"},
+			)), TarGz, out)
+			So(e, ShouldBeNil)
+			b, _ := ioutil.ReadFile(filepath.Join(out, "e"))
+			So(string(b), ShouldEqual, "f")
+		})
+		Convey("entries are not written through symlinks", func() {
+			e := ExtractArchive(bytes.NewReader(makeTarGz(
+				archiveEntry{name: "top/sub/", dir: true},
+				archiveEntry{name: "top/link", link: "sub"},
+				archiveEntry{name: "top/link/file.txt", content: "x", mode: 0644},
+			)), TarGz, tmp)
+			So(archiveError.Contains(e), ShouldBeTrue)
+			_, e = os.Stat(filepath.Join(tmp, "sub", "file.txt"))
+			So(os.IsNotExist(e), ShouldBeTrue)
+		})
+		Convey("extract a zip archive", func() {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			w, _ := zw.Create("top/docs/index.md")
+			w.Write([]byte("index"))
+			zw.Close()
+			e := ExtractArchive(&buf, Zip, tmp)
+			So(e, ShouldBeNil)
+			b, _ := ioutil.ReadFile(filepath.Join(tmp, "docs", "index.md"))
+			So(string(b), ShouldEqual, "index")
+		})
+	})
+}
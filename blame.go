@@ -0,0 +1,67 @@
+package gl
+
+import (
+	"net/url"
+	"sort"
+)
+
+const (
+	blame_url = "/projects/:id/repository/files/:file_path/blame"
+)
+
+// A range of consecutive lines of a file which were last changed by the
+// same commit. Start is the number of the first line, counted from 1.
+type BlameRange struct {
+	Commit Commit   `json:"commit"`
+	Lines  []string `json:"lines"`
+	Start  int      `json:"-"`
+}
+type BlameRanges []BlameRange
+
+// The number of lines of a file which were last changed by an author.
+type AuthorLines struct {
+	Name  string
+	EMail string
+	Lines int
+}
+
+// Returns the blame information of the file at the given ref.
+// Needs the v4 api.
+func (g *Client) Blame(id, filepath, ref string) (BlameRanges, error) {
+	u := expandUrl(blame_url, map[string]interface{}{":id": id, ":file_path": filepath})
+	vals := make(url.Values)
+	vals.Set("ref", ref)
+	var r BlameRanges
+	_, e := g.get(u, vals, nil, &r)
+	if e != nil {
+		return nil, e
+	}
+	line := 1
+	for i := range r {
+		r[i].Start = line
+		line += len(r[i].Lines)
+	}
+	return r, nil
+}
+
+// Returns the number of lines per author, the author with the most lines
+// comes first. Authors are identified by their email address.
+func (br BlameRanges) LinesByAuthor() []AuthorLines {
+	idx := make(map[string]int)
+	var res []AuthorLines
+	for _, r := range br {
+		key := r.Commit.AuthorEMail
+		if key == "" {
+			key = r.Commit.AuthorName
+		}
+		i, ok := idx[key]
+		if !ok {
+			i = len(res)
+			idx[key] = i
+			res = append(res, AuthorLines{Name: r.Commit.AuthorName, EMail: r.Commit.AuthorEMail})
+		}
+		res[i].Lines += len(r.Lines)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Lines > res[j].Lines })
+	return res
+}
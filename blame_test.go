@@ -0,0 +1,43 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestBlame(t *testing.T) {
+	Convey("blame test functions", t, func() {
+		Convey("blame a file", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`[
+{"commit":{"id":"a1","author_name":"ann","author_email":"ann@example.com"},"lines":["one","two"]},
+{"commit":{"id":"b1","author_name":"bob","author_email":"bob@example.com"},"lines":["three"]},
+{"commit":{"id":"a2","author_name":"Ann","author_email":"ann@example.com"},"lines":["four","five"]}
+]`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			b, e := cl.Blame("1", "src/main.go", "master")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(h.method, ShouldEqual, "GET")
+				So(h.rawpath, ShouldEqual, "/projects/1/repository/files/src%2Fmain.go/blame")
+				So(h.get("ref"), ShouldEqual, "master")
+			})
+			Convey("the ranges must be numbered", func() {
+				So(len(b), ShouldEqual, 3)
+				So(b[0].Start, ShouldEqual, 1)
+				So(b[1].Start, ShouldEqual, 3)
+				So(b[2].Start, ShouldEqual, 4)
+				So(b[2].Commit.Id, ShouldEqual, "a2")
+			})
+			Convey("the lines are aggregated per author", func() {
+				So(b.LinesByAuthor(), ShouldResemble, []AuthorLines{
+					{Name: "ann", EMail: "ann@example.com", Lines: 4},
+					{Name: "bob", EMail: "bob@example.com", Lines: 1},
+				})
+			})
+		})
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -21,6 +23,9 @@ const (
 	paramSudo    = "SUDO"
 
 	APIv3 = "/api/v3"
+	// Functions documented with "Needs the v4 api" only exist in the v4
+	// api, the All and Walk variants of such a function too.
+	APIv4 = "/api/v4"
 )
 
 var (
@@ -29,6 +34,7 @@ var (
 	invalidURLError = errors.NewClass("Invalid URL")
 	gitlabError     = errors.NewClass("gitlab")
 	jsonFormatError = errors.NewClass("jsonformat")
+	timeoutError    = errors.NewClass("timeout")
 
 	jsonUnmarshal = errors.GenSym()
 )
@@ -62,6 +68,11 @@ func OpenV3(hosturl string) (*Client, error) {
 	return New(hosturl, APIv3, true)
 }
 
+// Opens a connection to a gitlab server with the v4 api path.
+func OpenV4(hosturl string) (*Client, error) {
+	return New(hosturl, APIv4, true)
+}
+
 // Opens a connection to the given gitlab server. SSL certificates
 // are verified.
 func Open(hosturl, apipath string) (*Client, error) {
@@ -120,11 +131,10 @@ func (c *Client) SetLogger(l *log.Logger) {
 	c.log = l
 }
 
-func (g *Client) httpexecute(method, u string, params url.Values, paramInbody bool, body []byte, pg *Page) ([]byte, *Pagination, error) {
-
-	var req *http.Request
-	var err error
-
+// newRequest builds a request for the given api url. A given body is sent
+// with the content type ctype, otherwise the params are sent form encoded
+// in the body if paramInbody is set.
+func (g *Client) newRequest(method, u string, params url.Values, paramInbody bool, ctype string, body io.Reader, pg *Page) (*http.Request, error) {
 	newurl := *g.hostURL
 
 	parms := make(url.Values)
@@ -144,29 +154,50 @@ func (g *Client) httpexecute(method, u string, params url.Values, paramInbody bo
 	// if no body is given but the params should be in the body
 	// overwrite the body value
 	if paramInbody && len(params) > 0 && body == nil {
-		body = []byte(params.Encode())
+		body = strings.NewReader(params.Encode())
+		ctype = "application/x-www-form-urlencoded"
 		newurl.RawQuery = ""
 	}
-	if body != nil {
-		reader := bytes.NewReader(body)
-		req, err = http.NewRequest(method, newurl.String(), reader)
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	} else {
-		req, err = http.NewRequest(method, newurl.String(), nil)
+	req, err := http.NewRequest(method, newurl.String(), body)
+	if err != nil {
+		return nil, unknownError.Wrap(err)
+	}
+	if body != nil && ctype != "" {
+		req.Header.Set("Content-Type", ctype)
 	}
 	req.URL.Opaque = newurl.Opaque
 	req.URL.Path = ""
-	if err != nil {
-		return nil, nil, unknownError.Wrap(err)
-	}
 	// don't use Add-method, it canonicalizes header names
 	req.Header[privateToken] = []string{g.token}
 	if g.sudo != nil {
 		req.Header.Add(paramSudo, *g.sudo)
 	}
+	return req, nil
+}
+
+// do sends the request and returns the response if gitlab signals success.
+// The caller has to close the body of the response.
+func (g *Client) do(req *http.Request) (*http.Response, error) {
 	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, nil, networkError.Wrap(err)
+		return nil, networkError.Wrap(err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		contents, _ := ioutil.ReadAll(resp.Body)
+		msg := fmt.Sprintf("%s %s (%d): %s", req.Method, req.URL.String(), resp.StatusCode, strings.TrimSpace(string(contents)))
+		if g.log != nil {
+			g.log.Printf("%s", msg)
+		}
+		return nil, gitlabError.NewWith(msg, errhttp.SetStatusCode(resp.StatusCode), errhttp.SetErrorBody(string(contents)))
+	}
+	return resp, nil
+}
+
+func (g *Client) readResponse(req *http.Request) ([]byte, *Pagination, error) {
+	resp, err := g.do(req)
+	if err != nil {
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 	lnk := resp.Header.Get("Link")
@@ -175,15 +206,29 @@ func (g *Client) httpexecute(method, u string, params url.Values, paramInbody bo
 	if err != nil {
 		return nil, nil, networkError.Wrap(err)
 	}
+	return contents, p, nil
+}
 
-	if resp.StatusCode >= 400 {
-		msg := fmt.Sprintf("%s %s (%d): %s", method, req.URL.String(), resp.StatusCode, strings.TrimSpace(string(contents)))
-		if g.log != nil {
-			g.log.Printf("%s", msg)
+func (g *Client) httpexecute(method, u string, params url.Values, paramInbody bool, body []byte, pg *Page) ([]byte, *Pagination, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := g.newRequest(method, u, params, paramInbody, "application/x-www-form-urlencoded", rd, pg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.readResponse(req)
+}
+
+func (g *Client) unmarshal(buf []byte, target interface{}) error {
+	if target != nil {
+		err := json.Unmarshal(buf, target)
+		if err != nil {
+			return jsonFormatError.New("cannont unmarshal json: %s", string(buf))
 		}
-		return nil, nil, gitlabError.NewWith(msg)
 	}
-	return contents, p, nil
+	return nil
 }
 
 func (g *Client) execute(method, u string, params url.Values, paramInbody bool, body []byte, pg *Page, target interface{}) (*Pagination, error) {
@@ -194,15 +239,90 @@ func (g *Client) execute(method, u string, params url.Values, paramInbody bool,
 	if err != nil {
 		return nil, err
 	}
-	if target != nil {
-		err = json.Unmarshal(buf, target)
-		if err != nil {
-			return nil, jsonFormatError.New("cannont unmarshal json: %s", string(buf))
-		}
+	if err = g.unmarshal(buf, target); err != nil {
+		return nil, err
 	}
 	return pag, nil
 }
 
+// sendJSON sends the body json encoded and unmarshals the response into
+// target. Used for requests which cannot be form encoded, like arrays of
+// objects.
+func (g *Client) sendJSON(method, u string, body interface{}, target interface{}) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return jsonFormatError.Wrap(err)
+	}
+	req, err := g.newRequest(method, u, nil, false, "application/json", bytes.NewReader(buf), nil)
+	if err != nil {
+		return err
+	}
+	res, _, err := g.readResponse(req)
+	if g.log != nil {
+		g.log.Printf("%s %s [%s], buf = %s, err=%s\n", method, u, string(buf), string(res), err)
+	}
+	if err != nil {
+		return err
+	}
+	return g.unmarshal(res, target)
+}
+
+// stream executes a GET and returns the body of the response without
+// reading it. The caller has to close the returned reader.
+func (g *Client) stream(u string, params url.Values) (io.ReadCloser, error) {
+	req, err := g.newRequest("GET", u, params, false, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// upload posts the params and the content of the given reader as a
+// multipart form. The content is streamed as the file named filename
+// in the form field field.
+func (g *Client) upload(u string, params url.Values, field, filename string, content io.Reader, target interface{}) error {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, params, field, filename, content))
+	}()
+	req, err := g.newRequest("POST", u, nil, false, mw.FormDataContentType(), pr, nil)
+	if err != nil {
+		return err
+	}
+	buf, _, err := g.readResponse(req)
+	if g.log != nil {
+		g.log.Printf("POST %s [%+v], file = %s, buf = %s, err=%s\n", u, params, filename, string(buf), err)
+	}
+	if err != nil {
+		return err
+	}
+	return g.unmarshal(buf, target)
+}
+
+func writeMultipart(mw *multipart.Writer, params url.Values, field, filename string, content io.Reader) error {
+	for k, vals := range params {
+		for _, v := range vals {
+			if err := mw.WriteField(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(fw, content); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
 func (g *Client) get(u string, params url.Values, pg *Page, target interface{}) (*Pagination, error) {
 	return g.execute("GET", u, params, false, nil, pg, target)
 }
@@ -42,4 +42,15 @@ func TestClientCreation(t *testing.T) {
 			So(t.TLSClientConfig.InsecureSkipVerify, ShouldBeFalse)
 		})
 	})
+	Convey("Create a v4 client", t, func() {
+		host := "https://myhost"
+		c, err := OpenV4(host)
+		Convey("it should be secure and have a correct api path", func() {
+			So(err, ShouldBeNil)
+			So(c.hostURL.String(), ShouldEqual, host)
+			So(c.apiPath, ShouldEqual, APIv4)
+			t := c.client.Transport.(*http.Transport)
+			So(t.TLSClientConfig.InsecureSkipVerify, ShouldBeFalse)
+		})
+	})
 }
@@ -0,0 +1,99 @@
+package gl
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type CommitState string
+
+const (
+	StatusPending  = CommitState("pending")
+	StatusRunning  = CommitState("running")
+	StatusSuccess  = CommitState("success")
+	StatusFailed   = CommitState("failed")
+	StatusCanceled = CommitState("canceled")
+)
+
+const (
+	commitstatuses_url  = "/projects/:id/repository/commits/:sha/statuses"
+	setcommitstatus_url = "/projects/:id/statuses/:sha"
+)
+
+// The status of a commit as reported by a CI system.
+type CommitStatus struct {
+	Id           int         `json:"id,omitempty"`
+	Sha          string      `json:"sha,omitempty"`
+	Ref          string      `json:"ref,omitempty"`
+	Status       CommitState `json:"status,omitempty"`
+	Name         string      `json:"name,omitempty"`
+	TargetUrl    string      `json:"target_url,omitempty"`
+	Description  string      `json:"description,omitempty"`
+	Coverage     *float64    `json:"coverage,omitempty"`
+	AllowFailure bool        `json:"allow_failure,omitempty"`
+	Author       *User       `json:"author,omitempty"`
+	CreatedAt    *time.Time  `json:"created_at,omitempty"`
+	StartedAt    *time.Time  `json:"started_at,omitempty"`
+	FinishedAt   *time.Time  `json:"finished_at,omitempty"`
+}
+type CommitStatuses []CommitStatus
+
+// CommitStatusOptions contains the optional attributes of a commit
+// status. Only the non-nil values are sent to gitlab. Name is the
+// context which distinguishes several statuses of one commit.
+type CommitStatusOptions struct {
+	Ref         *string
+	Name        *string
+	TargetUrl   *string
+	Description *string
+	Coverage    *float64
+	PipelineId  *int
+}
+
+func (g *Client) CommitStatuses(id, sha string, ref, name *string, all bool, pg *Page) (CommitStatuses, *Pagination, error) {
+	var r CommitStatuses
+	u := expandUrl(commitstatuses_url, map[string]interface{}{":id": id, ":sha": sha})
+	vals := make(url.Values)
+	addString(vals, "ref", ref)
+	addString(vals, "name", name)
+	addBool(vals, "all", all)
+	pager, e := g.get(u, vals, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) AllCommitStatuses(id, sha string, ref, name *string, all bool) (CommitStatuses, error) {
+	var r CommitStatuses
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.CommitStatuses(id, sha, ref, name, all, pg)
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (g *Client) SetCommitStatus(id, sha string, state CommitState, opts *CommitStatusOptions) (*CommitStatus, error) {
+	u := expandUrl(setcommitstatus_url, map[string]interface{}{":id": id, ":sha": sha})
+	vals := make(url.Values)
+	vals.Set("state", string(state))
+	if opts != nil {
+		addString(vals, "ref", opts.Ref)
+		addString(vals, "name", opts.Name)
+		addString(vals, "target_url", opts.TargetUrl)
+		addString(vals, "description", opts.Description)
+		if opts.Coverage != nil {
+			vals.Set("coverage", strconv.FormatFloat(*opts.Coverage, 'f', -1, 64))
+		}
+		addInt(vals, "pipeline_id", opts.PipelineId)
+	}
+	var s CommitStatus
+	e := g.post(u, vals, &s)
+	if e != nil {
+		return nil, e
+	}
+	return &s, nil
+}
@@ -0,0 +1,49 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestCommitStatus(t *testing.T) {
+	Convey("Commit status functions", t, func() {
+		Convey("List the statuses of a commit", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return CommitStatuses{CommitStatus{Status: StatusSuccess}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			ref := "master"
+			st, _ := cl.AllCommitStatuses("1", "abc", &ref, nil, true)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/repository/commits/abc/statuses")
+				So(h.get("ref"), ShouldEqual, ref)
+				So(h.get("all"), ShouldEqual, "true")
+				So(h.values, hasnot, "name")
+				So(st[0].Status, ShouldEqual, StatusSuccess)
+			})
+		})
+		Convey("Set the status of a commit", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &CommitStatus{}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			name := "external-ci"
+			target := "https://ci/builds/4"
+			coverage := 87.5
+			cl.SetCommitStatus("1", "abc", StatusRunning, &CommitStatusOptions{Name: &name, TargetUrl: &target, Coverage: &coverage})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/statuses/abc")
+				So(h.get("state"), ShouldEqual, "running")
+				So(h.get("name"), ShouldEqual, name)
+				So(h.get("target_url"), ShouldEqual, target)
+				So(h.get("coverage"), ShouldEqual, "87.5")
+				So(h.values, hasnot, "ref", "description", "pipeline_id")
+			})
+		})
+	})
+}
@@ -8,8 +8,10 @@ type AccessLevel int
 type VisibilityLevel int
 type NotificationLevel int
 type State string
+type SortOrder string
 
 const (
+	NoAccess  = AccessLevel(0)
 	Guest     = AccessLevel(10)
 	Reporter  = AccessLevel(20)
 	Developer = AccessLevel(30)
@@ -27,6 +29,9 @@ const (
 
 	Active  State = "active"
 	Blocked       = "blocked"
+
+	Ascending  = SortOrder("asc")
+	Descending = SortOrder("desc")
 )
 
 const (
@@ -35,6 +40,19 @@ const (
 	txtjsonDateLayout = "2006-01-02"
 )
 
+// the name of the visibility level as used in query parameters
+func (v VisibilityLevel) name() string {
+	switch v {
+	case Private:
+		return "private"
+	case Internal:
+		return "internal"
+	case Public:
+		return "public"
+	}
+	return ""
+}
+
 // JsonDate represents a date of the form "YYYY-MM-DD" in a json string
 type JsonDate struct {
 	time.Time
@@ -0,0 +1,385 @@
+package gl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/spacemonkeygo/errors"
+)
+
+type DiffLineType int
+
+const (
+	ContextLine DiffLineType = iota
+	AddedLine
+	DeletedLine
+)
+
+var diffFormatError = errors.NewClass("diff format")
+
+// One line of a hunk. OldLine is 0 for added lines and NewLine is 0 for
+// deleted lines.
+type DiffLine struct {
+	Type    DiffLineType
+	Content string
+	OldLine int
+	NewLine int
+	// the line has no newline at the end of the file
+	NoNewline bool
+}
+
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// the text after the range information, e.g. the enclosing function
+	Section string
+	Lines   []DiffLine
+}
+
+// The parsed diff of one file.
+type FileDiff struct {
+	OldPath     string
+	NewPath     string
+	OldMode     string
+	NewMode     string
+	NewFile     bool
+	DeletedFile bool
+	RenamedFile bool
+	Binary      bool
+	Hunks       []DiffHunk
+}
+type FileDiffs []FileDiff
+
+// Parses the unified diff of the file. The paths and flags are taken from
+// the diff and completed with the information in the diff text.
+func (d *Diff) Parse() (*FileDiff, error) {
+	f := FileDiff{
+		OldPath:     d.OldPath,
+		NewPath:     d.NewPath,
+		NewFile:     d.NewFile,
+		DeletedFile: d.DeletedFile,
+		RenamedFile: d.RenamedFile,
+	}
+	if d.Amode != nil {
+		f.OldMode = *d.Amode
+	}
+	if d.Bmode != nil {
+		f.NewMode = *d.Bmode
+	}
+	if err := f.parse(splitLines(d.Diff)); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Parses all the given diffs, e.g. the diffs of a Comparison.
+func ParseDiffs(diffs []Diff) (FileDiffs, error) {
+	res := make(FileDiffs, 0, len(diffs))
+	for i := range diffs {
+		f, err := diffs[i].Parse()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *f)
+	}
+	return res, nil
+}
+
+// Parses the diffs of the comparison.
+func (c *Comparison) Files() (FileDiffs, error) {
+	return ParseDiffs(c.Diffs)
+}
+
+// Parses a raw unified diff which can contain several files, like the
+// output of git diff or diff -u. A new file starts at a "diff --git" line
+// or at a "---"/"+++" header outside of a hunk.
+func ParseUnifiedDiff(diff string) (FileDiffs, error) {
+	var res FileDiffs
+	var chunk []string
+	// the chunk already contains a ---/+++ header
+	var header bool
+	var oldLeft, newLeft int
+	flush := func() error {
+		header = false
+		if len(chunk) == 0 {
+			return nil
+		}
+		var f FileDiff
+		if err := f.parse(chunk); err != nil {
+			return err
+		}
+		res = append(res, f)
+		chunk = nil
+		return nil
+	}
+	lines := splitLines(diff)
+	for i, l := range lines {
+		switch {
+		case oldLeft > 0 || newLeft > 0:
+			// the line belongs to the hunk, its length is checked by parse
+			switch {
+			case strings.HasPrefix(l, "+"):
+				newLeft--
+			case strings.HasPrefix(l, "-"):
+				oldLeft--
+			case !strings.HasPrefix(l, "\\"):
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+			}
+		case strings.HasPrefix(l, "@@ "):
+			if h, err := parseHunkHeader(l); err == nil {
+				oldLeft, newLeft = h.OldLines, h.NewLines
+			}
+		case strings.HasPrefix(l, "diff --git "):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(l, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if header {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			header = true
+		}
+		chunk = append(chunk, l)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func (f *FileDiff) parse(lines []string) error {
+	var hunk *DiffHunk
+	var oldNum, newNum, oldLeft, newLeft int
+	for _, l := range lines {
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			line := DiffLine{}
+			if l == "" {
+				// some tools strip the blank of empty context lines
+				l = " "
+			}
+			switch l[0] {
+			case ' ':
+				line.Type = ContextLine
+				line.OldLine, line.NewLine = oldNum, newNum
+				oldNum, newNum = oldNum+1, newNum+1
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+			case '+':
+				line.Type = AddedLine
+				line.NewLine = newNum
+				newNum, newLeft = newNum+1, newLeft-1
+			case '-':
+				line.Type = DeletedLine
+				line.OldLine = oldNum
+				oldNum, oldLeft = oldNum+1, oldLeft-1
+			case '\\':
+				markNoNewline(hunk)
+				continue
+			default:
+				return diffFormatError.New("unexpected line in hunk of %s: %q", f.Path(), l)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return diffFormatError.New("hunk of %s is longer than its header", f.Path())
+			}
+			line.Content = l[1:]
+			hunk.Lines = append(hunk.Lines, line)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "@@ "):
+			h, err := parseHunkHeader(l)
+			if err != nil {
+				return err
+			}
+			f.Hunks = append(f.Hunks, *h)
+			hunk = &f.Hunks[len(f.Hunks)-1]
+			oldNum, newNum = h.OldStart, h.NewStart
+			oldLeft, newLeft = h.OldLines, h.NewLines
+		case strings.HasPrefix(l, "\\"):
+			if hunk != nil {
+				markNoNewline(hunk)
+			}
+		case strings.HasPrefix(l, "diff --git "):
+			if o, n, ok := parseGitHeader(l); ok {
+				f.OldPath, f.NewPath = o, n
+			}
+		case strings.HasPrefix(l, "--- "):
+			if p := headerPath(l[4:], "a/"); p != "" {
+				f.OldPath = p
+			} else {
+				f.NewFile = true
+			}
+		case strings.HasPrefix(l, "+++ "):
+			if p := headerPath(l[4:], "b/"); p != "" {
+				f.NewPath = p
+			} else {
+				f.DeletedFile = true
+			}
+		case strings.HasPrefix(l, "new file mode "):
+			f.NewFile = true
+			f.NewMode = l[len("new file mode "):]
+		case strings.HasPrefix(l, "deleted file mode "):
+			f.DeletedFile = true
+			f.OldMode = l[len("deleted file mode "):]
+		case strings.HasPrefix(l, "old mode "):
+			f.OldMode = l[len("old mode "):]
+		case strings.HasPrefix(l, "new mode "):
+			f.NewMode = l[len("new mode "):]
+		case strings.HasPrefix(l, "rename from "):
+			f.RenamedFile = true
+			f.OldPath = l[len("rename from "):]
+		case strings.HasPrefix(l, "rename to "):
+			f.RenamedFile = true
+			f.NewPath = l[len("rename to "):]
+		case strings.HasPrefix(l, "Binary files "), l == "GIT binary patch":
+			f.Binary = true
+		}
+	}
+	if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+		return diffFormatError.New("hunk of %s is shorter than its header", f.Path())
+	}
+	return nil
+}
+
+func markNoNewline(h *DiffHunk) {
+	if len(h.Lines) > 0 {
+		h.Lines[len(h.Lines)-1].NoNewline = true
+	}
+}
+
+// parses a header of the form "@@ -1,5 +1,6 @@ section"
+func parseHunkHeader(l string) (*DiffHunk, error) {
+	var h DiffHunk
+	parts := strings.SplitN(l, " ", 5)
+	if len(parts) < 4 || parts[3] != "@@" || !strings.HasPrefix(parts[1], "-") || !strings.HasPrefix(parts[2], "+") {
+		return nil, diffFormatError.New("invalid hunk header: %q", l)
+	}
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(parts[1][1:]); err != nil {
+		return nil, diffFormatError.New("invalid hunk header: %q", l)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(parts[2][1:]); err != nil {
+		return nil, diffFormatError.New("invalid hunk header: %q", l)
+	}
+	if len(parts) == 5 {
+		h.Section = parts[4]
+	}
+	return &h, nil
+}
+
+// parses "start,count" or "start" which means a count of 1
+func parseRange(r string) (int, int, error) {
+	pos := strings.Index(r, ",")
+	if pos < 0 {
+		start, err := strconv.Atoi(r)
+		return start, 1, err
+	}
+	start, err := strconv.Atoi(r[:pos])
+	if err != nil {
+		return 0, 0, err
+	}
+	count, err := strconv.Atoi(r[pos+1:])
+	return start, count, err
+}
+
+// parses "diff --git a/old b/new", paths with blanks are not supported
+func parseGitHeader(l string) (string, string, bool) {
+	parts := strings.Split(l[len("diff --git "):], " ")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "a/") || !strings.HasPrefix(parts[1], "b/") {
+		return "", "", false
+	}
+	return parts[0][2:], parts[1][2:], true
+}
+
+// the path of a ---/+++ line, empty for /dev/null
+func headerPath(p, prefix string) string {
+	if i := strings.Index(p, "\t"); i >= 0 {
+		p = p[:i]
+	}
+	if p == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(p, prefix)
+}
+
+// The path of the file after the change, or before the change for a
+// deleted file.
+func (f *FileDiff) Path() string {
+	if f.DeletedFile || f.NewPath == "" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+func (f *FileDiff) IsRename() bool {
+	return f.RenamedFile || (f.OldPath != "" && f.NewPath != "" && f.OldPath != f.NewPath)
+}
+
+func (f *FileDiff) lines(t DiffLineType) []DiffLine {
+	var res []DiffLine
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Type == t {
+				res = append(res, l)
+			}
+		}
+	}
+	return res
+}
+
+func (f *FileDiff) AddedLines() []DiffLine {
+	return f.lines(AddedLine)
+}
+
+func (f *FileDiff) DeletedLines() []DiffLine {
+	return f.lines(DeletedLine)
+}
+
+// Returns the diff of the file with the given old or new path or nil.
+func (fd FileDiffs) File(path string) *FileDiff {
+	for i := range fd {
+		if fd[i].NewPath == path || fd[i].OldPath == path {
+			return &fd[i]
+		}
+	}
+	return nil
+}
+
+// The added lines of the file with the given path.
+func (fd FileDiffs) AddedLines(path string) []DiffLine {
+	if f := fd.File(path); f != nil {
+		return f.AddedLines()
+	}
+	return nil
+}
+
+// Reports if the old or new path of a file matches the glob pattern. The
+// pattern uses the syntax of path.Match, additionally "**" matches any
+// number of directories.
+func (fd FileDiffs) Touches(pattern string) (bool, error) {
+	for _, f := range fd {
+		for _, p := range []string{f.OldPath, f.NewPath} {
+			if p == "" {
+				continue
+			}
+			ok, err := matchGlob(pattern, p)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
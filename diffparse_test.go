@@ -0,0 +1,145 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+const testHunks = `@@ -1,4 +1,6 @@ package main
+ package main
+-import "fmt"
++import (
++	"fmt"
++)
+ 
+ func main() {
+@@ -10,2 +11,2 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("b")
+\ No newline at end of file
++	fmt.Println("c")
+\ No newline at end of file
+`
+
+const testGitDiff = `diff --git a/docs/old.md b/docs/new.md
+similarity index 90%
+rename from docs/old.md
+rename to docs/new.md
+index 1111111..2222222 100644
+--- a/docs/old.md
++++ b/docs/new.md
+@@ -1 +1 @@
+--- old title
++++ new title
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..3333333
+Binary files /dev/null and b/logo.png differ
+diff --git a/main.go b/main.go
+deleted file mode 100644
+index 4444444..0000000
+--- a/main.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-
+`
+
+const testPlainDiff = `--- a/README.md	2020-01-01 10:00:00.000000000 +0100
++++ b/README.md	2020-01-02 10:00:00.000000000 +0100
+@@ -1,2 +1,2 @@
+ # gl
+--- old
++++ new
+--- a/main.go	2020-01-01 10:00:00.000000000 +0100
++++ b/main.go	2020-01-02 10:00:00.000000000 +0100
+@@ -1 +1,2 @@
+ package main
++// main
+`
+
+func TestDiffParse(t *testing.T) {
+	Convey("Given the diff of a file", t, func() {
+		d := Diff{OldPath: "main.go", NewPath: "main.go", Diff: testHunks}
+		f, err := d.Parse()
+		Convey("the hunks should be parsed", func() {
+			So(err, ShouldBeNil)
+			So(f.Path(), ShouldEqual, "main.go")
+			So(len(f.Hunks), ShouldEqual, 2)
+			So(f.Hunks[0].OldStart, ShouldEqual, 1)
+			So(f.Hunks[0].NewLines, ShouldEqual, 6)
+			So(f.Hunks[0].Section, ShouldEqual, "package main")
+			So(len(f.Hunks[0].Lines), ShouldEqual, 7)
+		})
+		Convey("the lines should have the correct numbers", func() {
+			l := f.Hunks[0].Lines
+			So(l[1].Type, ShouldEqual, DeletedLine)
+			So(l[1].OldLine, ShouldEqual, 2)
+			So(l[1].NewLine, ShouldEqual, 0)
+			So(l[3].Type, ShouldEqual, AddedLine)
+			So(l[3].Content, ShouldEqual, "\t\"fmt\"")
+			So(l[3].NewLine, ShouldEqual, 3)
+			So(l[5].Type, ShouldEqual, ContextLine)
+			So(l[5].Content, ShouldEqual, "")
+			So(l[6].OldLine, ShouldEqual, 4)
+			So(l[6].NewLine, ShouldEqual, 6)
+		})
+		Convey("the missing newlines should be marked", func() {
+			l := f.Hunks[1].Lines
+			So(l[1].NoNewline, ShouldBeTrue)
+			So(l[2].NoNewline, ShouldBeTrue)
+			So(l[0].NoNewline, ShouldBeFalse)
+		})
+		Convey("the added lines should be returned", func() {
+			added := FileDiffs{*f}.AddedLines("main.go")
+			So(len(added), ShouldEqual, 4)
+			So(added[3].NewLine, ShouldEqual, 12)
+			So(len(f.DeletedLines()), ShouldEqual, 2)
+		})
+	})
+	Convey("Given a raw git diff with several files", t, func() {
+		files, err := ParseUnifiedDiff(testGitDiff)
+		Convey("every file should be parsed", func() {
+			So(err, ShouldBeNil)
+			So(len(files), ShouldEqual, 3)
+		})
+		Convey("renames should be detected", func() {
+			f := files.File("docs/old.md")
+			So(f.IsRename(), ShouldBeTrue)
+			So(f.NewPath, ShouldEqual, "docs/new.md")
+			So(f.Hunks[0].Lines[0].Content, ShouldEqual, "-- old title")
+			So(f.Hunks[0].Lines[1].Content, ShouldEqual, "++ new title")
+		})
+		Convey("binary and deleted files should be detected", func() {
+			So(files[1].Binary, ShouldBeTrue)
+			So(files[1].NewFile, ShouldBeTrue)
+			So(files[2].DeletedFile, ShouldBeTrue)
+			So(files[2].Path(), ShouldEqual, "main.go")
+		})
+		Convey("paths should be matched by globs", func() {
+			ok, _ := files.Touches("docs/**")
+			So(ok, ShouldBeTrue)
+			ok, _ = files.Touches("**/*.png")
+			So(ok, ShouldBeTrue)
+			ok, _ = files.Touches("src/**/*.go")
+			So(ok, ShouldBeFalse)
+			_, err := files.Touches("[")
+			So(InvalidParam.Contains(err), ShouldBeTrue)
+		})
+	})
+	Convey("Given a plain unified diff with several files", t, func() {
+		files, err := ParseUnifiedDiff(testPlainDiff)
+		So(err, ShouldBeNil)
+		So(len(files), ShouldEqual, 2)
+		So(files[0].Path(), ShouldEqual, "README.md")
+		So(files[0].Hunks[0].Lines[1].Content, ShouldEqual, "-- old")
+		So(files[0].Hunks[0].Lines[2].Content, ShouldEqual, "++ new")
+		So(files[1].Path(), ShouldEqual, "main.go")
+		So(len(files[1].AddedLines()), ShouldEqual, 1)
+	})
+	Convey("Given a hunk which is shorter than its header", t, func() {
+		d := Diff{NewPath: "x", Diff: "@@ -1,3 +1,3 @@\n a\n"}
+		_, err := d.Parse()
+		So(diffFormatError.Contains(err), ShouldBeTrue)
+	})
+}
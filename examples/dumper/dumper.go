@@ -19,7 +19,7 @@ func main() {
 	git.Token(*token)
 
 	git.CreateProject("test", nil, nil, nil, false, false, false, false, false, nil, nil)
-	prjs, err := git.AllVisibleProjects()
+	prjs, err := git.AllVisibleProjects(nil)
 	if err != nil {
 		panic(err)
 	}
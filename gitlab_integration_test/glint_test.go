@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
 	"testing"
 	"time"
@@ -134,7 +133,7 @@ func TestGitlab(t *testing.T) {
 }
 
 func listAllProjects(t *testing.T, g *gl.Client, numExp int) {
-	prjs, e := g.AllProjects()
+	prjs, e := g.AllProjects(nil)
 	checkErrorCondition(t, e != nil, "cannot query projects")
 	checkErrorCondition(t, len(prjs) != numExp, "wrong number of projects")
 }
@@ -142,13 +141,13 @@ func listAllProjects(t *testing.T, g *gl.Client, numExp int) {
 func removeProjectsWithId(t *testing.T, git *gl.Client, prjs []gl.Project) {
 	t.Logf("Removing Projects from Gitlab with their ID's")
 	for _, p := range prjs {
-		e := git.RemoveProject(p.Id)
+		e := git.RemoveProject(p.Sid())
 		checkErrorCondition(t, e != nil, "cannot remove project '%s': %s", p.Name, e)
 	}
 }
 
 func fetchSingleProject(t *testing.T, git *gl.Client, ns, name string) {
-	pname := url.QueryEscape(fmt.Sprintf("%s/%s", ns, name))
+	pname := fmt.Sprintf("%s/%s", ns, name)
 	_, err := git.Project(pname)
 	checkErrorCondition(t, err != nil, "cannot fetch project %s/%s: %s", ns, pname, err)
 }
@@ -157,7 +156,7 @@ func fetchProjectsPaged(t *testing.T, git *gl.Client, pg, all int) {
 	page := gl.Page{Page: 0, PerPage: pg}
 	fetched := 0
 	for {
-		prj, pag, err := git.Projects(&page)
+		prj, pag, err := git.Projects(nil, &page)
 		checkErrorCondition(t, err != nil, "cannot fetch projects with page: %d: %s", page.Page, err)
 		checkErrorCondition(t, len(prj) != page.PerPage, "returned projects differ from Pagecount %d <> %d", len(prj), page.PerPage)
 		fetched += page.PerPage
@@ -85,12 +85,12 @@ func testTransfer(t *testing.T, git *gl.Client, admingit *gl.Client, u *gl.User)
 		nil, nil)
 
 	checkErrorCondition(t, e != nil, "cannot create project: '%s'", e)
-	defer git.RemoveProject(pr.Id)
+	defer git.RemoveProject(pr.Sid())
 
 	g, e := git.AddGroup("transfer_testgroup", "transfer_testpath")
 	checkErrorCondition(t, e != nil, "cannot create group: %s", e)
 	defer git.DeleteGroup(g.Id)
 
-	g, e = admingit.TransferProjectToGroup(g.Id, pr.Id)
+	g, e = admingit.TransferProjectToGroup(g.Id, pr.Sid())
 	checkErrorCondition(t, e != nil, "cannot transfer project to group: %s", e)
 }
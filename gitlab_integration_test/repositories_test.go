@@ -84,7 +84,7 @@ func testRepositories(t *testing.T, admingit *gl.Client) {
 		nil, nil)
 
 	checkErrorCondition(t, e != nil, "cannot create project: '%s'", e)
-	defer git.RemoveProject(pr.Id)
+	defer git.RemoveProject(pr.Sid())
 
 	uploadGitRepo(t, git, u.Id, pr.SshRepoUrl, git.Host())
 
@@ -7,31 +7,47 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 )
 
 type stub func(url.Values) (interface{}, error, int)
 
 type testrq struct {
-	method string
-	path   string
-	values url.Values
-	h      stub
-	encode bool
+	method  string
+	path    string
+	rawpath string
+	values  url.Values
+	files   map[string][]byte
+	body    []byte
+	h       stub
+	encode  bool
 }
 
 func (rq *testrq) get(k string) string {
 	return rq.values.Get(k)
 }
 func (rq *testrq) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	b, _ := ioutil.ReadAll(r.Body)
 	var v url.Values
-	if r.Method == "POST" || r.Method == "PUT" {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.ParseMultipartForm(1 << 20)
+		v = url.Values(r.MultipartForm.Value)
+		rq.files = make(map[string][]byte)
+		for k, fh := range r.MultipartForm.File {
+			f, _ := fh[0].Open()
+			rq.files[k], _ = ioutil.ReadAll(f)
+			f.Close()
+		}
+	} else if r.Header.Get("Content-Type") == "application/json" {
+		rq.body, _ = ioutil.ReadAll(r.Body)
+	} else if r.Method == "POST" || r.Method == "PUT" {
+		b, _ := ioutil.ReadAll(r.Body)
 		v, _ = url.ParseQuery(string(b))
 	} else {
 		v = r.URL.Query()
 	}
 	rq.method = r.Method
 	rq.path = r.URL.Path
+	rq.rawpath = r.URL.EscapedPath()
 	rq.values = v
 	res, err, code := rq.h(v)
 	if err != nil {
@@ -6,11 +6,12 @@ import (
 )
 
 const (
-	groups_url       = "/groups"
-	group_url        = "/groups/:id"
-	projectgroup_url = "/groups/:id/projects/:project_id"
-	groupmembers_url = "/groups/:id/members"
-	groupmember_url  = "/groups/:id/members/:user_id"
+	groups_url        = "/groups"
+	group_url         = "/groups/:id"
+	projectgroup_url  = "/groups/:id/projects/:project_id"
+	groupprojects_url = "/groups/:id/projects"
+	groupmembers_url  = "/groups/:id/members"
+	groupmember_url   = "/groups/:id/members/:user_id"
 )
 
 type Group struct {
@@ -62,6 +63,17 @@ func (g *Client) Group(gid int) (*Group, error) {
 	return &i, e
 }
 
+func (g *Client) GroupProjects(gid int, opts *ListProjectsOptions, pg *Page) (Projects, *Pagination, error) {
+	u := expandUrl(groupprojects_url, map[string]interface{}{":id": gid})
+	return g.projects(u, opts, pg)
+}
+
+func (g *Client) AllGroupProjects(gid int, opts *ListProjectsOptions) (Projects, error) {
+	return g.allProjects(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.GroupProjects(gid, opts, pg)
+	})
+}
+
 func (g *Client) AddGroup(name, path string) (*Group, error) {
 	v := make(url.Values)
 	v.Set("name", name)
@@ -72,7 +84,7 @@ func (g *Client) AddGroup(name, path string) (*Group, error) {
 	return &gr, e
 }
 
-func (g *Client) TransferProjectToGroup(gid, pid int) (*Group, error) {
+func (g *Client) TransferProjectToGroup(gid int, pid string) (*Group, error) {
 	v := make(url.Values)
 	u := expandUrl(projectgroup_url, map[string]interface{}{":id": gid, ":project_id": pid})
 	var gr Group
@@ -0,0 +1,159 @@
+package gl
+
+import (
+	"net/url"
+	"time"
+)
+
+const (
+	pullmirror_url       = "/projects/:id/mirror/pull"
+	remotemirrors_url    = "/projects/:id/remote_mirrors"
+	remotemirror_url     = "/projects/:id/remote_mirrors/:mirror_id"
+	remotemirrorsync_url = "/projects/:id/remote_mirrors/:mirror_id/sync"
+)
+
+// A push mirror of a project.
+type RemoteMirror struct {
+	Id                     int        `json:"id,omitempty"`
+	Enabled                bool       `json:"enabled,omitempty"`
+	Url                    string     `json:"url,omitempty"`
+	OnlyProtectedBranches  bool       `json:"only_protected_branches,omitempty"`
+	KeepDivergentRefs      bool       `json:"keep_divergent_refs,omitempty"`
+	UpdateStatus           string     `json:"update_status,omitempty"`
+	LastError              string     `json:"last_error,omitempty"`
+	LastUpdateAt           *time.Time `json:"last_update_at,omitempty"`
+	LastUpdateStartedAt    *time.Time `json:"last_update_started_at,omitempty"`
+	LastSuccessfulUpdateAt *time.Time `json:"last_successful_update_at,omitempty"`
+}
+type RemoteMirrors []RemoteMirror
+
+// RemoteMirrorOptions contains the settings of a push mirror. Only the
+// non-nil values are sent to gitlab.
+type RemoteMirrorOptions struct {
+	Enabled               *bool
+	OnlyProtectedBranches *bool
+	KeepDivergentRefs     *bool
+}
+
+func (o *RemoteMirrorOptions) values() url.Values {
+	vals := make(url.Values)
+	if o != nil {
+		addOptBool(vals, "enabled", o.Enabled)
+		addOptBool(vals, "only_protected_branches", o.OnlyProtectedBranches)
+		addOptBool(vals, "keep_divergent_refs", o.KeepDivergentRefs)
+	}
+	return vals
+}
+
+// The pull mirror of a project.
+type PullMirror struct {
+	Id                     int        `json:"id,omitempty"`
+	Url                    string     `json:"url,omitempty"`
+	UpdateStatus           string     `json:"update_status,omitempty"`
+	LastError              string     `json:"last_error,omitempty"`
+	LastUpdateAt           *time.Time `json:"last_update_at,omitempty"`
+	LastUpdateStartedAt    *time.Time `json:"last_update_started_at,omitempty"`
+	LastSuccessfulUpdateAt *time.Time `json:"last_successful_update_at,omitempty"`
+}
+
+// PullMirrorOptions contains the settings of a pull mirror. Only the
+// non-nil values are sent to gitlab.
+type PullMirrorOptions struct {
+	TriggerBuilds             *bool
+	OnlyProtectedBranches     *bool
+	OverwriteDivergedBranches *bool
+}
+
+// Needs the v4 api.
+func (g *Client) RemoteMirrors(id string, pg *Page) (RemoteMirrors, *Pagination, error) {
+	var r RemoteMirrors
+	u := expandUrl(remotemirrors_url, map[string]interface{}{":id": id})
+	pager, e := g.get(u, nil, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) AllRemoteMirrors(id string) (RemoteMirrors, error) {
+	var r RemoteMirrors
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.RemoteMirrors(id, pg)
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Needs the v4 api.
+func (g *Client) AddRemoteMirror(id string, murl string, opts *RemoteMirrorOptions) (*RemoteMirror, error) {
+	u := expandUrl(remotemirrors_url, map[string]interface{}{":id": id})
+	v := opts.values()
+	v.Set("url", murl)
+	var m RemoteMirror
+	e := g.post(u, v, &m)
+	if e != nil {
+		return nil, e
+	}
+	return &m, nil
+}
+
+// Needs the v4 api.
+func (g *Client) EditRemoteMirror(id string, mid int, opts *RemoteMirrorOptions) (*RemoteMirror, error) {
+	u := expandUrl(remotemirror_url, map[string]interface{}{":id": id, ":mirror_id": mid})
+	var m RemoteMirror
+	e := g.put(u, opts.values(), &m)
+	if e != nil {
+		return nil, e
+	}
+	return &m, nil
+}
+
+// Needs the v4 api.
+func (g *Client) SyncRemoteMirror(id string, mid int) error {
+	u := expandUrl(remotemirrorsync_url, map[string]interface{}{":id": id, ":mirror_id": mid})
+	return g.post(u, nil, nil)
+}
+
+// Needs the v4 api.
+func (g *Client) DeleteRemoteMirror(id string, mid int) error {
+	u := expandUrl(remotemirror_url, map[string]interface{}{":id": id, ":mirror_id": mid})
+	return g.delete(u, nil, nil)
+}
+
+// Needs the v4 api.
+func (g *Client) PullMirror(id string) (*PullMirror, error) {
+	u := expandUrl(pullmirror_url, map[string]interface{}{":id": id})
+	var m PullMirror
+	_, e := g.get(u, nil, nil, &m)
+	if e != nil {
+		return nil, e
+	}
+	return &m, nil
+}
+
+// Configures the project to pull its repository from murl. An existing
+// pull mirror is updated. Needs the v4 api.
+func (g *Client) ConfigurePullMirror(id string, murl string, opts *PullMirrorOptions) (*Project, error) {
+	mirror := true
+	po := EditProjectOptions{ImportUrl: &murl, Mirror: &mirror}
+	if opts != nil {
+		po.MirrorTriggerBuilds = opts.TriggerBuilds
+		po.OnlyMirrorProtectedBranches = opts.OnlyProtectedBranches
+		po.MirrorOverwritesDivergedBranches = opts.OverwriteDivergedBranches
+	}
+	return g.EditProject(id, &po)
+}
+
+// Needs the v4 api.
+func (g *Client) DisablePullMirror(id string) (*Project, error) {
+	mirror := false
+	return g.EditProject(id, &EditProjectOptions{Mirror: &mirror})
+}
+
+// Needs the v4 api.
+func (g *Client) SyncPullMirror(id string) error {
+	u := expandUrl(pullmirror_url, map[string]interface{}{":id": id})
+	return g.post(u, nil, nil)
+}
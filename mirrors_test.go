@@ -0,0 +1,85 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestMirrors(t *testing.T) {
+	Convey("Mirror functions", t, func() {
+		Convey("List the push mirrors", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return RemoteMirrors{RemoteMirror{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.AllRemoteMirrors("1")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/remote_mirrors")
+			})
+		})
+		Convey("Add a push mirror", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &RemoteMirror{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			enabled := true
+			cl.AddRemoteMirror("1", "https://mirror/repo.git", &RemoteMirrorOptions{Enabled: &enabled})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/remote_mirrors")
+				So(h.get("url"), ShouldEqual, "https://mirror/repo.git")
+				So(h.get("enabled"), ShouldEqual, "true")
+				So(h.values, hasnot, "only_protected_branches", "keep_divergent_refs")
+			})
+		})
+		Convey("Edit, sync and delete a push mirror", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &RemoteMirror{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			only := true
+			cl.EditRemoteMirror("1", 4, &RemoteMirrorOptions{OnlyProtectedBranches: &only})
+			So(h.method, ShouldEqual, "PUT")
+			So(h.path, ShouldEqual, "/projects/1/remote_mirrors/4")
+			So(h.get("only_protected_branches"), ShouldEqual, "true")
+			cl.SyncRemoteMirror("1", 4)
+			So(h.method, ShouldEqual, "POST")
+			So(h.path, ShouldEqual, "/projects/1/remote_mirrors/4/sync")
+			cl.DeleteRemoteMirror("1", 4)
+			So(h.method, ShouldEqual, "DELETE")
+			So(h.path, ShouldEqual, "/projects/1/remote_mirrors/4")
+		})
+		Convey("Configure a pull mirror", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Project{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			trigger := false
+			cl.ConfigurePullMirror("1", "https://upstream/repo.git", &PullMirrorOptions{TriggerBuilds: &trigger})
+			Convey("the project must be updated", func() {
+				So(h.method, ShouldEqual, "PUT")
+				So(h.path, ShouldEqual, "/projects/1")
+				So(h.get("import_url"), ShouldEqual, "https://upstream/repo.git")
+				So(h.get("mirror"), ShouldEqual, "true")
+				So(h.get("mirror_trigger_builds"), ShouldEqual, "false")
+				So(h.values, hasnot, "only_mirror_protected_branches", "mirror_overwrites_diverged_branches")
+			})
+		})
+		Convey("Trigger a pull mirror update", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.SyncPullMirror("1")
+			So(h.method, ShouldEqual, "POST")
+			So(h.path, ShouldEqual, "/projects/1/mirror/pull")
+		})
+	})
+}
@@ -2,6 +2,7 @@ package gl
 
 import (
 	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -12,6 +13,17 @@ const (
 	snippetnote_url  = "/projects/:id/snippets/:snippet_id/notes/:note_id"
 	mergenotes_url   = "/projects/:id/merge_requests/:merge_request_id/notes"
 	mergenote_url    = "/projects/:id/merge_requests/:merge_request_id/notes/:note_id"
+
+	commitcomments_url    = "/projects/:id/repository/commits/:sha/comments"
+	commitdiscussions_url = "/projects/:id/repository/commits/:sha/discussions"
+)
+
+// The side of a diff a comment refers to.
+type LineSide string
+
+const (
+	OldSide = LineSide("old")
+	NewSide = LineSide("new")
 )
 
 type Note struct {
@@ -24,9 +36,41 @@ type Note struct {
 	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
 	CreatedAt  *time.Time `json:"created_at,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	// set for inline comments on a commit
+	Path     string        `json:"path,omitempty"`
+	Line     int           `json:"line,omitempty"`
+	LineType LineSide      `json:"line_type,omitempty"`
+	Position *NotePosition `json:"position,omitempty"`
 }
 type Notes []Note
 
+// The position of a note in a diff, returned for the notes of a
+// discussion.
+type NotePosition struct {
+	BaseSha      string `json:"base_sha,omitempty"`
+	StartSha     string `json:"start_sha,omitempty"`
+	HeadSha      string `json:"head_sha,omitempty"`
+	PositionType string `json:"position_type,omitempty"`
+	OldPath      string `json:"old_path,omitempty"`
+	NewPath      string `json:"new_path,omitempty"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// A thread of notes.
+type Discussion struct {
+	Id             string `json:"id,omitempty"`
+	IndividualNote bool   `json:"individual_note,omitempty"`
+	Notes          Notes  `json:"notes,omitempty"`
+}
+type Discussions []Discussion
+
+// gitlab returns the text of a commit comment as "note"
+type commitComment struct {
+	Note
+	Text string `json:"note,omitempty"`
+}
+
 func (g *Client) notes(endpoint string, pid string, ntype string, nkey int, pg *Page) (Notes, *Pagination, error) {
 	var p Notes
 
@@ -120,3 +164,78 @@ func (g *Client) CreateMergeNote(pid string, mid int, body string) (*Note, error
 	e := g.post(u, v, &n)
 	return &n, e
 }
+
+func (g *Client) CommitComments(pid string, sha string, pg *Page) (Notes, *Pagination, error) {
+	var c []commitComment
+	u := expandUrl(commitcomments_url, map[string]interface{}{":id": pid, ":sha": sha})
+	pager, e := g.get(u, nil, pg, &c)
+	if e != nil {
+		return nil, nil, e
+	}
+	n := make(Notes, len(c))
+	for i := range c {
+		n[i] = c[i].note()
+	}
+	return n, pager, nil
+}
+func (g *Client) AllCommitComments(pid string, sha string) (Notes, error) {
+	var n Notes
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.CommitComments(pid, sha, pg)
+	}, &n)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+func (g *Client) CreateCommitComment(pid string, sha string, body string) (*Note, error) {
+	return g.createCommitComment(pid, sha, body, make(url.Values))
+}
+
+// Creates a comment on a line of a file in the diff of the commit. The
+// side selects if line is a line number in the old or the new file.
+func (g *Client) CreateInlineCommitComment(pid string, sha string, body string, path string, line int, side LineSide) (*Note, error) {
+	v := make(url.Values)
+	v.Set("path", path)
+	v.Set("line", strconv.Itoa(line))
+	v.Set("line_type", string(side))
+	return g.createCommitComment(pid, sha, body, v)
+}
+func (g *Client) createCommitComment(pid string, sha string, body string, v url.Values) (*Note, error) {
+	u := expandUrl(commitcomments_url, map[string]interface{}{":id": pid, ":sha": sha})
+	v.Set("note", body)
+	var c commitComment
+	e := g.post(u, v, &c)
+	if e != nil {
+		return nil, e
+	}
+	n := c.note()
+	return &n, nil
+}
+
+func (c *commitComment) note() Note {
+	n := c.Note
+	n.Body = c.Text
+	return n
+}
+
+// Needs the v4 api.
+func (g *Client) CommitDiscussions(pid string, sha string, pg *Page) (Discussions, *Pagination, error) {
+	var d Discussions
+	u := expandUrl(commitdiscussions_url, map[string]interface{}{":id": pid, ":sha": sha})
+	pager, e := g.get(u, nil, pg, &d)
+	if e != nil {
+		return nil, nil, e
+	}
+	return d, pager, nil
+}
+func (g *Client) AllCommitDiscussions(pid string, sha string) (Discussions, error) {
+	var d Discussions
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.CommitDiscussions(pid, sha, pg)
+	}, &d)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,76 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestCommitNotes(t *testing.T) {
+	Convey("commit note test functions", t, func() {
+		Convey("list the comments of a commit", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`[{"note":"looks good","author":{"username":"ann"},"path":"main.go","line":12,"line_type":"new"}]`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			n, e := cl.AllCommitComments("1", "mysha")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/repository/commits/mysha/comments")
+				So(len(n), ShouldEqual, 1)
+				So(n[0].Body, ShouldEqual, "looks good")
+				So(n[0].Path, ShouldEqual, "main.go")
+				So(n[0].Line, ShouldEqual, 12)
+				So(n[0].LineType, ShouldEqual, NewSide)
+			})
+		})
+		Convey("create a comment on a commit", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`{"note":"thanks"}`), nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			n, e := cl.CreateCommitComment("1", "mysha", "thanks")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(n.Body, ShouldEqual, "thanks")
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/repository/commits/mysha/comments")
+				So(h.get("note"), ShouldEqual, "thanks")
+				So(h.values, hasnot, "path", "line", "line_type")
+			})
+		})
+		Convey("create an inline comment on a commit", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`{"note":"typo","path":"README.md","line":3,"line_type":"old"}`), nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			n, _ := cl.CreateInlineCommitComment("1", "mysha", "typo", "README.md", 3, OldSide)
+			Convey("check if the request was correct", func() {
+				So(h.get("note"), ShouldEqual, "typo")
+				So(h.get("path"), ShouldEqual, "README.md")
+				So(h.get("line"), ShouldEqual, "3")
+				So(h.get("line_type"), ShouldEqual, "old")
+				So(n.LineType, ShouldEqual, OldSide)
+			})
+		})
+		Convey("list the discussions of a commit", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`[{"id":"abc","individual_note":false,"notes":[{"id":7,"body":"why?","position":{"new_path":"main.go","new_line":5,"position_type":"text"}}]}]`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			d, e := cl.AllCommitDiscussions("1", "mysha")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(h.path, ShouldEqual, "/projects/1/repository/commits/mysha/discussions")
+				So(d[0].Id, ShouldEqual, "abc")
+				So(d[0].Notes[0].Body, ShouldEqual, "why?")
+				So(d[0].Notes[0].Position.NewLine, ShouldEqual, 5)
+			})
+		})
+	})
+}
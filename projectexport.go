@@ -0,0 +1,202 @@
+package gl
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ExportStatus string
+type ImportStatus string
+
+const (
+	ExportNone         = ExportStatus("none")
+	ExportQueued       = ExportStatus("queued")
+	ExportStarted      = ExportStatus("started")
+	ExportFinished     = ExportStatus("finished")
+	ExportRegenerating = ExportStatus("regeneration_in_progress")
+
+	ImportNone      = ImportStatus("none")
+	ImportScheduled = ImportStatus("scheduled")
+	ImportStarted   = ImportStatus("started")
+	ImportFinished  = ImportStatus("finished")
+	ImportFailed    = ImportStatus("failed")
+)
+
+const (
+	project_export_url   = "/projects/:id/export"
+	project_download_url = "/projects/:id/export/download"
+	project_import_url   = "/projects/import"
+	import_status_url    = "/projects/:id/import"
+)
+
+type ExportLinks struct {
+	ApiUrl string `json:"api_url,omitempty"`
+	WebUrl string `json:"web_url,omitempty"`
+}
+
+// ProjectExport is the state of the export of a project.
+type ProjectExport struct {
+	Id                int          `json:"id,omitempty"`
+	Description       string       `json:"description,omitempty"`
+	Name              string       `json:"name,omitempty"`
+	NameWithNamespace string       `json:"name_with_namespace,omitempty"`
+	Path              string       `json:"path,omitempty"`
+	PathWithNamespace string       `json:"path_with_namespace,omitempty"`
+	Created           *time.Time   `json:"created_at,omitempty"`
+	Status            ExportStatus `json:"export_status,omitempty"`
+	Links             *ExportLinks `json:"_links,omitempty"`
+}
+
+// ProjectImport is the state of the import of a project.
+type ProjectImport struct {
+	Id                int          `json:"id,omitempty"`
+	Description       string       `json:"description,omitempty"`
+	Name              string       `json:"name,omitempty"`
+	NameWithNamespace string       `json:"name_with_namespace,omitempty"`
+	Path              string       `json:"path,omitempty"`
+	PathWithNamespace string       `json:"path_with_namespace,omitempty"`
+	Created           *time.Time   `json:"created_at,omitempty"`
+	Status            ImportStatus `json:"import_status,omitempty"`
+	Error             string       `json:"import_error,omitempty"`
+}
+
+func (p *ProjectImport) Sid() string {
+	return strconv.Itoa(p.Id)
+}
+
+// Needs the v4 api.
+func (g *Client) ScheduleProjectExport(id string) error {
+	u := expandUrl(project_export_url, map[string]interface{}{":id": id})
+	return g.post(u, nil, nil)
+}
+
+// Needs the v4 api.
+func (g *Client) ProjectExportStatus(id string) (*ProjectExport, error) {
+	u := expandUrl(project_export_url, map[string]interface{}{":id": id})
+	var p ProjectExport
+	_, e := g.get(u, nil, nil, &p)
+	if e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
+// Returns the content of a finished export. The caller has to close the
+// returned reader. Needs the v4 api.
+func (g *Client) DownloadProjectExport(id string) (io.ReadCloser, error) {
+	u := expandUrl(project_download_url, map[string]interface{}{":id": id})
+	return g.stream(u, nil)
+}
+
+// Polls the export status every interval until the export is finished. If
+// it is not finished after timeout, an error is returned.
+// Needs the v4 api.
+func (g *Client) WaitForProjectExport(id string, interval, timeout time.Duration) (*ProjectExport, error) {
+	return g.waitForExport(id, true, interval, timeout)
+}
+
+// waits until the export is finished. If started is false, an older
+// export is finished and the status must first show that the new one
+// is running.
+func (g *Client) waitForExport(id string, started bool, interval, timeout time.Duration) (*ProjectExport, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		p, e := g.ProjectExportStatus(id)
+		if e != nil {
+			return nil, e
+		}
+		switch p.Status {
+		case ExportFinished:
+			if started {
+				return p, nil
+			}
+		case ExportNone:
+			// the export is not scheduled yet
+		default:
+			started = true
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, timeoutError.New("export of project %s not finished after %s, status: %s", id, timeout, p.Status)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Schedules an export of the project, waits until it is finished and
+// writes the exported archive to w. If the project has an older finished
+// export, the new export must be seen running before it is downloaded.
+// Needs the v4 api.
+func (g *Client) ExportProject(id string, w io.Writer, interval, timeout time.Duration) error {
+	prev, e := g.ProjectExportStatus(id)
+	if e != nil {
+		return e
+	}
+	if e := g.ScheduleProjectExport(id); e != nil {
+		return e
+	}
+	if _, e := g.waitForExport(id, prev.Status != ExportFinished, interval, timeout); e != nil {
+		return e
+	}
+	rc, e := g.DownloadProjectExport(id)
+	if e != nil {
+		return e
+	}
+	defer rc.Close()
+	if _, e = io.Copy(w, rc); e != nil {
+		return networkError.Wrap(e)
+	}
+	return nil
+}
+
+// Uploads an exported archive and imports it as a new project with the
+// given path in the namespace. The import runs asynchronously, use
+// WaitForProjectImport to wait until it is finished. Needs the v4 api.
+func (g *Client) ImportProject(namespace, path string, name *string, overwrite bool, archive io.Reader) (*ProjectImport, error) {
+	vals := make(url.Values)
+	vals.Set("namespace", namespace)
+	vals.Set("path", path)
+	addString(vals, "name", name)
+	addBool(vals, "overwrite", overwrite)
+	var p ProjectImport
+	e := g.upload(project_import_url, vals, "file", path+".tar.gz", archive, &p)
+	if e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
+// Needs the v4 api.
+func (g *Client) ProjectImportStatus(id string) (*ProjectImport, error) {
+	u := expandUrl(import_status_url, map[string]interface{}{":id": id})
+	var p ProjectImport
+	_, e := g.get(u, nil, nil, &p)
+	if e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
+// Polls the import status every interval until the import is finished. A
+// failed import or an import which is not finished after timeout returns
+// an error. Needs the v4 api.
+func (g *Client) WaitForProjectImport(id string, interval, timeout time.Duration) (*ProjectImport, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		p, e := g.ProjectImportStatus(id)
+		if e != nil {
+			return nil, e
+		}
+		switch p.Status {
+		case ImportFinished:
+			return p, nil
+		case ImportFailed:
+			return nil, gitlabError.New("import of project %s failed: %s", id, p.Error)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, timeoutError.New("import of project %s not finished after %s, status: %s", id, timeout, p.Status)
+		}
+		time.Sleep(interval)
+	}
+}
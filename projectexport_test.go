@@ -0,0 +1,116 @@
+package gl
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestProjectExport(t *testing.T) {
+	Convey("Project export and import functions", t, func() {
+		Convey("Query the export status", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProjectExport{Status: ExportStarted}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			p, _ := cl.ProjectExportStatus("group/project")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.rawpath, ShouldEqual, "/projects/group%2Fproject/export")
+				So(p.Status, ShouldEqual, ExportStarted)
+			})
+		})
+		Convey("Download an export", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte("tarball"), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			var buf bytes.Buffer
+			rc, err := cl.DownloadProjectExport("1")
+			So(err, ShouldBeNil)
+			buf.ReadFrom(rc)
+			rc.Close()
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/export/download")
+				So(buf.String(), ShouldEqual, "tarball")
+			})
+		})
+		Convey("Waiting for an export times out", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProjectExport{Status: ExportQueued}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			_, err := cl.WaitForProjectExport("1", time.Millisecond, 5*time.Millisecond)
+			So(timeoutError.Contains(err), ShouldBeTrue)
+		})
+		Convey("Export a project which has an older export", func() {
+			var h *testrq
+			var calls []string
+			status := []ExportStatus{ExportFinished, ExportFinished, ExportStarted, ExportFinished}
+			h = thp(func(v url.Values) (interface{}, error, int) {
+				calls = append(calls, h.method+" "+h.path)
+				switch {
+				case h.method == "POST":
+					return []byte(`{"message":"202 Accepted"}`), nil, 202
+				case h.path == "/projects/1/export":
+					s := status[0]
+					status = status[1:]
+					return []byte(`{"id":1,"export_status":"` + string(s) + `"}`), nil, 200
+				}
+				return []byte("new tarball"), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			var buf bytes.Buffer
+			err := cl.ExportProject("1", &buf, time.Millisecond, time.Second)
+			Convey("the old export must not be downloaded", func() {
+				So(err, ShouldBeNil)
+				So(calls, ShouldResemble, []string{
+					"GET /projects/1/export",
+					"POST /projects/1/export",
+					"GET /projects/1/export",
+					"GET /projects/1/export",
+					"GET /projects/1/export",
+					"GET /projects/1/export/download",
+				})
+				So(buf.String(), ShouldEqual, "new tarball")
+			})
+		})
+		Convey("Import an exported archive", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProjectImport{Id: 3, Status: ImportScheduled}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			name := "new name"
+			p, err := cl.ImportProject("group", "newpath", &name, true, bytes.NewBufferString("tarball"))
+			Convey("the archive must be uploaded as a multipart form", func() {
+				So(err, ShouldBeNil)
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/import")
+				So(h.get("namespace"), ShouldEqual, "group")
+				So(h.get("path"), ShouldEqual, "newpath")
+				So(h.get("name"), ShouldEqual, name)
+				So(h.get("overwrite"), ShouldEqual, "true")
+				So(string(h.files["file"]), ShouldEqual, "tarball")
+				So(p.Sid(), ShouldEqual, "3")
+			})
+		})
+		Convey("A failed import is an error", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProjectImport{Status: ImportFailed, Error: "broken"}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			_, err := cl.WaitForProjectImport("3", time.Millisecond, time.Second)
+			So(h.path, ShouldEqual, "/projects/3/import")
+			So(gitlabError.Contains(err), ShouldBeTrue)
+		})
+	})
+}
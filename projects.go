@@ -1,7 +1,9 @@
 package gl
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/url"
 	"strconv"
 	"time"
@@ -14,6 +16,14 @@ const (
 	projects_all_url   = "/projects/all"
 	projects_owned_url = "/projects/owned"
 	project_url        = "/projects/:id"
+	archive_project    = "/projects/:id/archive"
+	unarchive_project  = "/projects/:id/unarchive"
+	star_project       = "/projects/:id/star"
+	unstar_project     = "/projects/:id/unstar"
+	transfer_project   = "/projects/:id/transfer"
+	share_project      = "/projects/:id/share"
+	unshare_project    = "/projects/:id/share/:group_id"
+	uploads_url        = "/projects/:id/uploads"
 	project_events_url = "/projects/:id/events"
 	userprojects_url   = "/projects/user/:user_id"
 	members_url        = "/projects/:id/members"
@@ -22,6 +32,7 @@ const (
 	hook_url           = "/projects/:id/hooks/:hook_id"
 	branches_url       = "/projects/:id/repository/branches"
 	branch_url         = "/projects/:id/repository/branches/:branch"
+	mergedbranches_url = "/projects/:id/repository/merged_branches"
 	forkfrom_url       = "/projects/:id/fork/:forked_from_id"
 	fork_url           = "/projects/:id/fork"
 	search_url         = "/projects/search/:query"
@@ -77,6 +88,8 @@ type Project struct {
 	NameWithSpaces       string          `json:"name_with_spaces,omitempty"`
 	Path                 string          `json:"path,omitempty"`
 	PathWithSpaces       string          `json:"path_with_spaces,omitempty"`
+	NameWithNamespace    string          `json:"name_with_namespace,omitempty"`
+	PathWithNamespace    string          `json:"path_with_namespace,omitempty"`
 	IssuesEnabled        bool            `json:"issues_enabled,omitempty"`
 	MergeRequestsEnabled bool            `json:"merge_requests_enabled,omitempty"`
 	WikiEnabled          bool            `json:"wiki_enabled,omitempty"`
@@ -84,11 +97,178 @@ type Project struct {
 	Created              time.Time       `json:"created_at, omitempty"`
 	LastActivity         time.Time       `json:"last_activity_at, omitempty"`
 	Archived             bool            `json:"archived, omitempty"`
+	StarCount            int             `json:"star_count,omitempty"`
+	ImportUrl            string          `json:"import_url,omitempty"`
+	ImportStatus         ImportStatus    `json:"import_status,omitempty"`
+	ImportError          string          `json:"import_error,omitempty"`
+	Mirror               bool            `json:"mirror,omitempty"`
 	Permissions          Permissions     `json:"permissions,omitempty"`
 	Namespace            *Namespace      `json:"namespace,omitempty"`
+	SharedWithGroups     []SharedGroup   `json:"shared_with_groups,omitempty"`
+	// only returned if requested, see ListProjectsOptions
+	Statistics *ProjectStatistics `json:"statistics,omitempty"`
 }
 type Projects []Project
 
+// A group the project is shared with.
+type SharedGroup struct {
+	GroupId       int         `json:"group_id,omitempty"`
+	GroupName     string      `json:"group_name,omitempty"`
+	GroupFullPath string      `json:"group_full_path,omitempty"`
+	Access        AccessLevel `json:"group_access_level,omitempty"`
+	ExpiresAt     *JsonDate   `json:"expires_at,omitempty"`
+}
+
+type ProjectOrderBy string
+
+const (
+	OrderProjectsById           = ProjectOrderBy("id")
+	OrderProjectsByName         = ProjectOrderBy("name")
+	OrderProjectsByPath         = ProjectOrderBy("path")
+	OrderProjectsByCreated      = ProjectOrderBy("created_at")
+	OrderProjectsByUpdated      = ProjectOrderBy("updated_at")
+	OrderProjectsByLastActivity = ProjectOrderBy("last_activity_at")
+)
+
+// ListProjectsOptions filters and sorts the project listings. Only the
+// non-nil values are sent to gitlab.
+type ListProjectsOptions struct {
+	Archived           *bool
+	Visibility         *VisibilityLevel
+	OrderBy            *ProjectOrderBy
+	Sort               *SortOrder
+	Search             *string
+	Starred            *bool
+	Simple             *bool
+	Membership         *bool
+	Owned              *bool
+	MinAccessLevel     *AccessLevel
+	LastActivityAfter  *time.Time
+	LastActivityBefore *time.Time
+	Statistics         *bool
+}
+
+func (o *ListProjectsOptions) values() url.Values {
+	vals := make(url.Values)
+	addOptBool(vals, "archived", o.Archived)
+	if o.Visibility != nil {
+		vals.Set("visibility", o.Visibility.name())
+	}
+	if o.OrderBy != nil {
+		vals.Set("order_by", string(*o.OrderBy))
+	}
+	if o.Sort != nil {
+		vals.Set("sort", string(*o.Sort))
+	}
+	addString(vals, "search", o.Search)
+	addOptBool(vals, "starred", o.Starred)
+	addOptBool(vals, "simple", o.Simple)
+	addOptBool(vals, "membership", o.Membership)
+	addOptBool(vals, "owned", o.Owned)
+	if o.MinAccessLevel != nil {
+		l := int(*o.MinAccessLevel)
+		addInt(vals, "min_access_level", &l)
+	}
+	addTime(vals, "last_activity_after", o.LastActivityAfter)
+	addTime(vals, "last_activity_before", o.LastActivityBefore)
+	addOptBool(vals, "statistics", o.Statistics)
+	return vals
+}
+
+type MergeMethod string
+
+const (
+	MergeCommit      = MergeMethod("merge")
+	RebaseMerge      = MergeMethod("rebase_merge")
+	FastForwardMerge = MergeMethod("ff")
+)
+
+// EditProjectOptions contains the attributes which can be changed with
+// EditProject. Only the non-nil values are sent to gitlab. The avatar
+// and the access levels of the single features are not supported.
+type EditProjectOptions struct {
+	Name                 *string
+	Path                 *string
+	Description          *string
+	DefaultBranch        *string
+	IssuesEnabled        *bool
+	MergeRequestsEnabled *bool
+	WikiEnabled          *bool
+	SnippetsEnabled      *bool
+	Public               *bool
+	Visibility           *VisibilityLevel
+	TagList              []string
+
+	LfsEnabled               *bool
+	RequestAccessEnabled     *bool
+	SharedRunnersEnabled     *bool
+	BuildsEnabled            *bool
+	PublicBuilds             *bool
+	BuildTimeout             *int
+	BuildCoverageRegex       *string
+	CiConfigPath             *string
+	ContainerRegistryEnabled *bool
+
+	MergeMethod                               *MergeMethod
+	OnlyAllowMergeIfBuildSucceeds             *bool
+	OnlyAllowMergeIfAllDiscussionsAreResolved *bool
+	RemoveSourceBranchAfterMerge              *bool
+	ResolveOutdatedDiffDiscussions            *bool
+	PrintingMergeRequestLinkEnabled           *bool
+	ApprovalsBeforeMerge                      *int
+
+	// pull mirror settings
+	ImportUrl                        *string
+	Mirror                           *bool
+	MirrorTriggerBuilds              *bool
+	OnlyMirrorProtectedBranches      *bool
+	MirrorOverwritesDivergedBranches *bool
+}
+
+func (o *EditProjectOptions) values() url.Values {
+	vals := make(url.Values)
+	addString(vals, "name", o.Name)
+	addString(vals, "path", o.Path)
+	addString(vals, "description", o.Description)
+	addString(vals, "default_branch", o.DefaultBranch)
+	addOptBool(vals, "issues_enabled", o.IssuesEnabled)
+	addOptBool(vals, "merge_requests_enabled", o.MergeRequestsEnabled)
+	addOptBool(vals, "wiki_enabled", o.WikiEnabled)
+	addOptBool(vals, "snippets_enabled", o.SnippetsEnabled)
+	addOptBool(vals, "public", o.Public)
+	if o.Visibility != nil {
+		v := int(*o.Visibility)
+		addInt(vals, "visibility_level", &v)
+	}
+	for _, t := range o.TagList {
+		vals.Add("tag_list[]", t)
+	}
+	addOptBool(vals, "lfs_enabled", o.LfsEnabled)
+	addOptBool(vals, "request_access_enabled", o.RequestAccessEnabled)
+	addOptBool(vals, "shared_runners_enabled", o.SharedRunnersEnabled)
+	addOptBool(vals, "builds_enabled", o.BuildsEnabled)
+	addOptBool(vals, "public_builds", o.PublicBuilds)
+	addInt(vals, "build_timeout", o.BuildTimeout)
+	addString(vals, "build_coverage_regex", o.BuildCoverageRegex)
+	addString(vals, "ci_config_path", o.CiConfigPath)
+	addOptBool(vals, "container_registry_enabled", o.ContainerRegistryEnabled)
+	if o.MergeMethod != nil {
+		vals.Set("merge_method", string(*o.MergeMethod))
+	}
+	addOptBool(vals, "only_allow_merge_if_build_succeeds", o.OnlyAllowMergeIfBuildSucceeds)
+	addOptBool(vals, "only_allow_merge_if_all_discussions_are_resolved", o.OnlyAllowMergeIfAllDiscussionsAreResolved)
+	addOptBool(vals, "remove_source_branch_after_merge", o.RemoveSourceBranchAfterMerge)
+	addOptBool(vals, "resolve_outdated_diff_discussions", o.ResolveOutdatedDiffDiscussions)
+	addOptBool(vals, "printing_merge_request_link_enabled", o.PrintingMergeRequestLinkEnabled)
+	addInt(vals, "approvals_before_merge", o.ApprovalsBeforeMerge)
+	addString(vals, "import_url", o.ImportUrl)
+	addOptBool(vals, "mirror", o.Mirror)
+	addOptBool(vals, "mirror_trigger_builds", o.MirrorTriggerBuilds)
+	addOptBool(vals, "only_mirror_protected_branches", o.OnlyMirrorProtectedBranches)
+	addOptBool(vals, "mirror_overwrites_diverged_branches", o.MirrorOverwritesDivergedBranches)
+	return vals
+}
+
 type EventData struct {
 	Before       string        `json:"before,omitempty"`
 	After        string        `json:"after,omitempty"`
@@ -144,9 +324,13 @@ func (p *Project) Sid() string {
 	return strconv.Itoa(p.Id)
 }
 
-func (g *Client) projects(purl string, pg *Page) (Projects, *Pagination, error) {
+func (g *Client) projects(purl string, opts *ListProjectsOptions, pg *Page) (Projects, *Pagination, error) {
 	var p Projects
-	pager, e := g.get(purl, nil, pg, &p)
+	var vals url.Values
+	if opts != nil {
+		vals = opts.values()
+	}
+	pager, e := g.get(purl, vals, pg, &p)
 	if e != nil {
 		return nil, nil, e
 	}
@@ -162,37 +346,37 @@ func (g *Client) allProjects(f fetchFunc) (Projects, error) {
 	return p, nil
 }
 
-func (g *Client) VisibleProjects(pg *Page) (Projects, *Pagination, error) {
-	return g.projects(projects_url, pg)
+func (g *Client) VisibleProjects(opts *ListProjectsOptions, pg *Page) (Projects, *Pagination, error) {
+	return g.projects(projects_url, opts, pg)
 }
-func (g *Client) Projects(pg *Page) (Projects, *Pagination, error) {
-	return g.projects(projects_all_url, pg)
+func (g *Client) Projects(opts *ListProjectsOptions, pg *Page) (Projects, *Pagination, error) {
+	return g.projects(projects_all_url, opts, pg)
 }
-func (g *Client) OwnedProjects(pg *Page) (Projects, *Pagination, error) {
-	return g.projects(projects_owned_url, pg)
+func (g *Client) OwnedProjects(opts *ListProjectsOptions, pg *Page) (Projects, *Pagination, error) {
+	return g.projects(projects_owned_url, opts, pg)
 }
-func (g *Client) Search(name string, pg *Page) (Projects, *Pagination, error) {
+func (g *Client) Search(name string, opts *ListProjectsOptions, pg *Page) (Projects, *Pagination, error) {
 	u := expandUrl(search_url, map[string]interface{}{":query": name})
-	return g.projects(u, pg)
+	return g.projects(u, opts, pg)
 }
-func (g *Client) AllVisibleProjects() (Projects, error) {
+func (g *Client) AllVisibleProjects(opts *ListProjectsOptions) (Projects, error) {
 	return g.allProjects(func(pg *Page) (interface{}, *Pagination, error) {
-		return g.VisibleProjects(pg)
+		return g.VisibleProjects(opts, pg)
 	})
 }
-func (g *Client) AllOwnedProjects() (Projects, error) {
+func (g *Client) AllOwnedProjects(opts *ListProjectsOptions) (Projects, error) {
 	return g.allProjects(func(pg *Page) (interface{}, *Pagination, error) {
-		return g.OwnedProjects(pg)
+		return g.OwnedProjects(opts, pg)
 	})
 }
-func (g *Client) AllProjects() (Projects, error) {
+func (g *Client) AllProjects(opts *ListProjectsOptions) (Projects, error) {
 	return g.allProjects(func(pg *Page) (interface{}, *Pagination, error) {
-		return g.Projects(pg)
+		return g.Projects(opts, pg)
 	})
 }
-func (g *Client) SearchAll(name string) (Projects, error) {
+func (g *Client) SearchAll(name string, opts *ListProjectsOptions) (Projects, error) {
 	return g.allProjects(func(pg *Page) (interface{}, *Pagination, error) {
-		return g.Search(name, pg)
+		return g.Search(name, opts, pg)
 	})
 }
 func (g *Client) Project(id string) (*Project, error) {
@@ -205,6 +389,26 @@ func (g *Client) Project(id string) (*Project, error) {
 	return &p, nil
 }
 
+// A file uploaded to a project.
+type ProjectUpload struct {
+	Alt      string `json:"alt,omitempty"`
+	Url      string `json:"url,omitempty"`
+	FullPath string `json:"full_path,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// Uploads the content as a file of the project, the result contains the
+// url and a markdown link to use in descriptions and comments.
+func (g *Client) UploadFile(id string, filename string, content io.Reader) (*ProjectUpload, error) {
+	u := expandUrl(uploads_url, map[string]interface{}{":id": id})
+	var p ProjectUpload
+	e := g.upload(u, nil, "file", filename, content, &p)
+	if e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
 func (g *Client) Events(id string, pg *Page) (Events, *Pagination, error) {
 	var p Events
 	u := expandUrl(project_events_url, map[string]interface{}{":id": id})
@@ -238,6 +442,14 @@ func (g *Client) CreateUserProject(name string, uid int, description, defaultbra
 		issuesEnabled, mergeRQenabled, wikiEnabled, snippetsEnabled,
 		public, vis, importUrl)
 }
+
+// Creates a project with the default features enabled by importing the
+// repository at importUrl. The import runs asynchronously, use
+// WaitForProjectImport to wait until it is finished.
+func (g *Client) CreateProjectFromUrl(name string, path *string, nsid *int, importUrl string) (*Project, error) {
+	return g.createProject(projects_url, nil, name, path, nsid, nil, nil, true, true, true, true,
+		false, nil, &importUrl)
+}
 func (g *Client) createProject(purl string, urlparms map[string]interface{}, name string, path *string, nsid *int, description, defbranch *string,
 	issuesEnabled, mergeRQenabled, wikiEnabled, snippetsEnabled bool,
 	public bool, vis *VisibilityLevel, importUrl *string) (*Project, error) {
@@ -266,7 +478,87 @@ func (g *Client) createProject(purl string, urlparms map[string]interface{}, nam
 	return &p, err
 }
 
-func (g *Client) RemoveProject(id int) error {
+func (g *Client) EditProject(id string, opts *EditProjectOptions) (*Project, error) {
+	u := expandUrl(project_url, map[string]interface{}{":id": id})
+	var vals url.Values
+	if opts != nil {
+		vals = opts.values()
+	}
+	var p Project
+	e := g.put(u, vals, &p)
+	if e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
+// gitlab answers 304 without a body if the project already has the
+// requested state, e.g. it is already starred. Then the project is read.
+func (g *Client) projectAction(purl string, id string, vals url.Values) (*Project, error) {
+	u := expandUrl(purl, map[string]interface{}{":id": id})
+	buf, _, e := g.httpexecute("POST", u, vals, true, nil, nil)
+	if e != nil {
+		return nil, e
+	}
+	if len(bytes.TrimSpace(buf)) == 0 {
+		return g.Project(id)
+	}
+	var p Project
+	if e := g.unmarshal(buf, &p); e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
+func (g *Client) ArchiveProject(id string) (*Project, error) {
+	return g.projectAction(archive_project, id, nil)
+}
+func (g *Client) UnarchiveProject(id string) (*Project, error) {
+	return g.projectAction(unarchive_project, id, nil)
+}
+func (g *Client) StarProject(id string) (*Project, error) {
+	return g.projectAction(star_project, id, nil)
+}
+
+// Needs the v4 api.
+func (g *Client) UnstarProject(id string) (*Project, error) {
+	return g.projectAction(unstar_project, id, nil)
+}
+
+// Transfers the project to the given namespace, which can be the id or the
+// path of a user or group namespace. Needs the v4 api.
+func (g *Client) TransferProject(id string, namespace string) (*Project, error) {
+	u := expandUrl(transfer_project, map[string]interface{}{":id": id})
+	vals := make(url.Values)
+	vals.Set("namespace", namespace)
+	var p Project
+	e := g.put(u, vals, &p)
+	if e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
+
+// Shares the project with the group, the members of the group get the
+// given access level to the project. If expires is given, the share is
+// removed on this date.
+func (g *Client) ShareProject(id string, gid int, level AccessLevel, expires *time.Time) error {
+	u := expandUrl(share_project, map[string]interface{}{":id": id})
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("group_access", fmt.Sprintf("%d", level))
+	if expires != nil {
+		vals.Set("expires_at", expires.Format(txtjsonDateLayout))
+	}
+	return g.post(u, vals, nil)
+}
+
+func (g *Client) UnshareProject(id string, gid int) error {
+	u := expandUrl(unshare_project, map[string]interface{}{":id": id, ":group_id": gid})
+	return g.delete(u, nil, nil)
+}
+
+func (g *Client) RemoveProject(id string) error {
 	u := expandUrl(project_url, map[string]interface{}{":id": id})
 	return g.delete(u, nil, nil)
 }
@@ -293,7 +585,7 @@ func (g *Client) TeamMembers(id string, query *string, pg *Page) ([]Member, *Pag
 	return p, pager, nil
 }
 
-func (g *Client) TeamMember(pid, uid int) (*Member, error) {
+func (g *Client) TeamMember(pid string, uid int) (*Member, error) {
 	var p Member
 	u := expandUrl(member_url, map[string]interface{}{":id": pid, ":user_id": uid})
 	_, e := g.get(u, nil, nil, &p)
@@ -407,11 +699,11 @@ func (g *Client) DeleteHook(id string, hid int) (*Hook, error) {
 	return &h, nil
 }
 
-func (g *Client) CreateFork(id int, forkedFrom int) error {
+func (g *Client) CreateFork(id string, forkedFrom string) error {
 	u := expandUrl(forkfrom_url, map[string]interface{}{":id": id, ":forked_from_id": forkedFrom})
 	return g.post(u, nil, nil)
 }
-func (g *Client) DeleteFork(id int) error {
+func (g *Client) DeleteFork(id string) error {
 	u := expandUrl(fork_url, map[string]interface{}{":id": id})
 	return g.delete(u, nil, nil)
 }
@@ -3,8 +3,11 @@ package gl
 import (
 	"fmt"
 	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestProjects(t *testing.T) {
@@ -74,6 +77,20 @@ func TestProjects(t *testing.T) {
 			})
 		})
 
+		Convey("Creating a project from a remote repository", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Project{ImportStatus: ImportScheduled}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			p, _ := cl.CreateProjectFromUrl("imported", nil, nil, "https://example.com/repo.git")
+			Convey("the import url must be sent", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, projects_url)
+				So(h.get("import_url"), ShouldEqual, "https://example.com/repo.git")
+				So(p.ImportStatus, ShouldEqual, ImportScheduled)
+			})
+		})
 		Convey("Search for projects", func() {
 			name := "searchfor"
 			h := th(func(v url.Values) (interface{}, error, int) {
@@ -81,12 +98,55 @@ func TestProjects(t *testing.T) {
 			})
 			srv, cl := StubHandler(h)
 			defer srv.Close()
-			cl.SearchAll(name)
+			cl.SearchAll(name, nil)
 			Convey("check if the request was correct", func() {
 				So(h.method, ShouldEqual, "GET")
 				So(h.path, ShouldEqual, fmt.Sprintf("/projects/search/%s", name))
 			})
 		})
+		Convey("List projects with filter options", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return []Project{Project{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			archived := false
+			vis := Internal
+			order := OrderProjectsByLastActivity
+			sort := Descending
+			after := time.Date(2014, time.August, 10, 10, 10, 10, 0, time.UTC)
+			cl.AllProjects(&ListProjectsOptions{
+				Archived:          &archived,
+				Visibility:        &vis,
+				OrderBy:           &order,
+				Sort:              &sort,
+				LastActivityAfter: &after,
+			})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, projects_all_url)
+				So(h.get("archived"), ShouldEqual, "false")
+				So(h.get("visibility"), ShouldEqual, "internal")
+				So(h.get("order_by"), ShouldEqual, "last_activity_at")
+				So(h.get("sort"), ShouldEqual, "desc")
+				So(h.get("last_activity_after"), ShouldEqual, "2014-08-10T10:10:10Z")
+				So(h.values, hasnot, "search", "starred", "simple", "membership", "owned")
+			})
+		})
+		Convey("List the projects of a group", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return []Project{Project{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			search := "api"
+			cl.AllGroupProjects(3, &ListProjectsOptions{Search: &search})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/groups/3/projects")
+				So(h.get("search"), ShouldEqual, search)
+			})
+		})
 		Convey("Creating a userproject with a name and a defaultbranch", func() {
 			name := "testproject"
 			user := 5
@@ -121,12 +181,149 @@ func TestProjects(t *testing.T) {
 			})
 			srv, cl := StubHandler(h)
 			defer srv.Close()
-			cl.RemoveProject(54)
+			cl.RemoveProject("54")
 			Convey("should invoke a DELETE on the correct url", func() {
 				So(h.method, ShouldEqual, "DELETE")
 				So(h.path, ShouldEqual, "/projects/54")
 			})
 		})
+		Convey("get a project by its namespace and path", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Project{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.Project("group/sub/project")
+			Convey("the path must be escaped as one segment", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.rawpath, ShouldEqual, "/projects/group%2Fsub%2Fproject")
+			})
+		})
+		Convey("editing a project", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Project{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			branch := "develop"
+			wiki := false
+			ff := FastForwardMerge
+			timeout := 3600
+			cl.EditProject("54", &EditProjectOptions{
+				Description:   &prj.Description,
+				DefaultBranch: &branch,
+				WikiEnabled:   &wiki,
+				Visibility:    &prj.Visibility,
+				LfsEnabled:    &wiki,
+				MergeMethod:   &ff,
+				BuildTimeout:  &timeout,
+				TagList:       []string{"go", "api"},
+			})
+			Convey("should invoke a PUT with only the given attributes", func() {
+				So(h.method, ShouldEqual, "PUT")
+				So(h.path, ShouldEqual, "/projects/54")
+				So(h.get("description"), ShouldEqual, prj.Description)
+				So(h.get("default_branch"), ShouldEqual, branch)
+				So(h.get("wiki_enabled"), ShouldEqual, "false")
+				So(h.get("visibility_level"), ShouldEqual, fmt.Sprintf("%d", prj.Visibility))
+				So(h.get("lfs_enabled"), ShouldEqual, "false")
+				So(h.get("merge_method"), ShouldEqual, "ff")
+				So(h.get("build_timeout"), ShouldEqual, "3600")
+				So(h.values["tag_list[]"], ShouldResemble, []string{"go", "api"})
+				So(h.values, hasnot,
+					"name", "path",
+					"shared_runners_enabled",
+					"only_allow_merge_if_build_succeeds",
+					"issues_enabled",
+					"merge_requests_enabled",
+					"snippets_enabled",
+					"public")
+			})
+		})
+		Convey("archiving and starring a project", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Project{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.ArchiveProject("54")
+			So(h.method, ShouldEqual, "POST")
+			So(h.path, ShouldEqual, "/projects/54/archive")
+			cl.UnarchiveProject("54")
+			So(h.path, ShouldEqual, "/projects/54/unarchive")
+			cl.StarProject("54")
+			So(h.path, ShouldEqual, "/projects/54/star")
+			cl.UnstarProject("54")
+			So(h.path, ShouldEqual, "/projects/54/unstar")
+		})
+		Convey("starring an already starred project", func() {
+			var paths []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.Method+" "+r.URL.Path)
+				if r.Method == "POST" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte(`{"id":54,"star_count":3}`))
+			}))
+			defer srv.Close()
+			cl, _ := Open(srv.URL, "")
+			p, e := cl.StarProject("54")
+			Convey("the 304 is no error and the project is read", func() {
+				So(e, ShouldBeNil)
+				So(p.StarCount, ShouldEqual, 3)
+				So(paths, ShouldResemble, []string{"POST /projects/54/star", "GET /projects/54"})
+			})
+		})
+		Convey("share a project with a group", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			expires := time.Date(2015, time.March, 1, 0, 0, 0, 0, time.UTC)
+			cl.ShareProject("54", 7, Reporter, &expires)
+			Convey("should invoke a POST with the group and access level", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/54/share")
+				So(h.get("group_id"), ShouldEqual, "7")
+				So(h.get("group_access"), ShouldEqual, fmt.Sprintf("%d", Reporter))
+				So(h.get("expires_at"), ShouldEqual, "2015-03-01")
+			})
+			cl.UnshareProject("54", 7)
+			Convey("and unsharing should invoke a DELETE", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, "/projects/54/share/7")
+			})
+		})
+		Convey("the shared groups of a project", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`{"id":54,"shared_with_groups":[{"group_id":7,"group_name":"team","group_full_path":"org/team","group_access_level":20,"expires_at":"2015-03-01"}]}`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			p, err := cl.Project("54")
+			Convey("should be unmarshalled", func() {
+				So(err, ShouldBeNil)
+				So(len(p.SharedWithGroups), ShouldEqual, 1)
+				So(p.SharedWithGroups[0].GroupFullPath, ShouldEqual, "org/team")
+				So(p.SharedWithGroups[0].Access, ShouldEqual, Reporter)
+				So(p.SharedWithGroups[0].ExpiresAt.Month(), ShouldEqual, time.March)
+			})
+		})
+		Convey("transfer a project to another namespace", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Project{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.TransferProject("54", "othergroup")
+			Convey("should invoke a PUT with the namespace", func() {
+				So(h.method, ShouldEqual, "PUT")
+				So(h.path, ShouldEqual, "/projects/54/transfer")
+				So(h.get("namespace"), ShouldEqual, "othergroup")
+			})
+		})
 	})
 	Convey("test the team members functions", t, func() {
 		Convey("list the team members", func() {
@@ -145,7 +342,7 @@ func TestProjects(t *testing.T) {
 		})
 
 		Convey("get a specific member", func() {
-			pid := 1
+			pid := "1"
 			uid := 2
 
 			h := th(func(v url.Values) (interface{}, error, int) {
@@ -157,7 +354,7 @@ func TestProjects(t *testing.T) {
 			cl.TeamMember(pid, uid)
 			Convey("the url should be correct", func() {
 				So(h.method, ShouldEqual, "GET")
-				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%d/members/%d", pid, uid))
+				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%s/members/%d", pid, uid))
 			})
 		})
 		Convey("create a member", func() {
@@ -353,10 +550,55 @@ func TestProjects(t *testing.T) {
 			})
 		})
 	})
+	Convey("create and delete branches", t, func() {
+		Convey("create a branch from a ref", func() {
+			pid := "54"
+			h := th(func(v url.Values) (interface{}, error, int) {
+				var b Branch
+				return &b, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.CreateBranch(pid, "release/1.0", "master")
+			Convey("check the values of the http request", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%s/repository/branches", pid))
+				So(h.get("branch"), ShouldEqual, "release/1.0")
+				So(h.get("ref"), ShouldEqual, "master")
+			})
+		})
+		Convey("delete a branch", func() {
+			pid := "54"
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 204
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			e := cl.DeleteBranch(pid, "feature/x")
+			So(e, ShouldBeNil)
+			Convey("check the values of the http request", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.rawpath, ShouldEqual, fmt.Sprintf("/projects/%s/repository/branches/feature%%2Fx", pid))
+			})
+		})
+		Convey("delete the merged branches", func() {
+			pid := "54"
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 202
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.DeleteMergedBranches(pid)
+			Convey("check the values of the http request", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%s/repository/merged_branches", pid))
+			})
+		})
+	})
 	Convey("now create and delete a fork", t, func() {
 		Convey("first create a fork", func() {
-			pid := 54
-			from := 55
+			pid := "54"
+			from := "55"
 			h := th(func(v url.Values) (interface{}, error, int) {
 				return nil, nil, 200
 			})
@@ -366,11 +608,11 @@ func TestProjects(t *testing.T) {
 			So(e, ShouldBeNil)
 			Convey("check the values of the http request", func() {
 				So(h.method, ShouldEqual, "POST")
-				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%d/fork/%d", pid, from))
+				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%s/fork/%s", pid, from))
 			})
 		})
 		Convey("delete a fork", func() {
-			pid := 54
+			pid := "54"
 			h := th(func(v url.Values) (interface{}, error, int) {
 				return nil, nil, 200
 			})
@@ -380,7 +622,7 @@ func TestProjects(t *testing.T) {
 			So(e, ShouldBeNil)
 			Convey("check the values of the http request", func() {
 				So(h.method, ShouldEqual, "DELETE")
-				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%d/fork", pid))
+				So(h.path, ShouldEqual, fmt.Sprintf("/projects/%s/fork", pid))
 			})
 		})
 	})
@@ -0,0 +1,106 @@
+package gl
+
+import (
+	"net/url"
+)
+
+const (
+	languages_url = "/projects/:id/languages"
+)
+
+// The storage statistics of a project, the sizes are in bytes.
+type ProjectStatistics struct {
+	CommitCount      int   `json:"commit_count"`
+	StorageSize      int64 `json:"storage_size"`
+	RepositorySize   int64 `json:"repository_size"`
+	WikiSize         int64 `json:"wiki_size"`
+	LfsObjectsSize   int64 `json:"lfs_objects_size"`
+	JobArtifactsSize int64 `json:"job_artifacts_size"`
+	PackagesSize     int64 `json:"packages_size"`
+	SnippetsSize     int64 `json:"snippets_size"`
+	UploadsSize      int64 `json:"uploads_size"`
+}
+
+// The languages of a repository with their share in percent.
+type Languages map[string]float64
+
+// Returns the statistics of the project. Needs the v4 api.
+func (g *Client) ProjectStatistics(id string) (*ProjectStatistics, error) {
+	var p Project
+	u := expandUrl(project_url, map[string]interface{}{":id": id})
+	vals := make(url.Values)
+	addBool(vals, "statistics", true)
+	_, e := g.get(u, vals, nil, &p)
+	if e != nil {
+		return nil, e
+	}
+	if p.Statistics == nil {
+		return nil, gitlabError.New("no statistics returned for project %s", id)
+	}
+	return p.Statistics, nil
+}
+
+// Needs the v4 api.
+func (g *Client) ProjectLanguages(id string) (Languages, error) {
+	var l Languages
+	u := expandUrl(languages_url, map[string]interface{}{":id": id})
+	_, e := g.get(u, nil, nil, &l)
+	if e != nil {
+		return nil, e
+	}
+	return l, nil
+}
+
+func (s *ProjectStatistics) add(o *ProjectStatistics) {
+	s.CommitCount += o.CommitCount
+	s.StorageSize += o.StorageSize
+	s.RepositorySize += o.RepositorySize
+	s.WikiSize += o.WikiSize
+	s.LfsObjectsSize += o.LfsObjectsSize
+	s.JobArtifactsSize += o.JobArtifactsSize
+	s.PackagesSize += o.PackagesSize
+	s.SnippetsSize += o.SnippetsSize
+	s.UploadsSize += o.UploadsSize
+}
+
+// Sums the statistics of the projects. Projects without statistics are
+// skipped, list the projects with the Statistics option to get them.
+func (ps Projects) Statistics() ProjectStatistics {
+	var s ProjectStatistics
+	for _, p := range ps {
+		if p.Statistics != nil {
+			s.add(p.Statistics)
+		}
+	}
+	return s
+}
+
+// Fetches the languages of all projects and returns their share of the
+// summed repository size in percent. All projects must have statistics.
+// Needs the v4 api.
+func (g *Client) ProjectsLanguages(ps Projects) (Languages, error) {
+	sizes := make(map[string]float64)
+	var total float64
+	for _, p := range ps {
+		if p.Statistics == nil {
+			return nil, InvalidParam.New("project %d has no statistics", p.Id)
+		}
+		size := float64(p.Statistics.RepositorySize)
+		if size == 0 {
+			continue
+		}
+		l, e := g.ProjectLanguages(p.Sid())
+		if e != nil {
+			return nil, e
+		}
+		for name, pct := range l {
+			sizes[name] += pct / 100 * size
+			total += pct / 100 * size
+		}
+	}
+	res := make(Languages)
+	for name, b := range sizes {
+		res[name] = b / total * 100
+	}
+	return res, nil
+}
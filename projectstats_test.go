@@ -0,0 +1,81 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestProjectStatistics(t *testing.T) {
+	Convey("project statistics test functions", t, func() {
+		Convey("read the statistics of a project", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`{"id":1,"statistics":{"commit_count":37,"storage_size":1038090,"repository_size":1038000,"lfs_objects_size":90,"job_artifacts_size":12}}`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			s, e := cl.ProjectStatistics("1")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1")
+				So(h.get("statistics"), ShouldEqual, "true")
+				So(s.CommitCount, ShouldEqual, 37)
+				So(s.RepositorySize, ShouldEqual, int64(1038000))
+				So(s.LfsObjectsSize, ShouldEqual, int64(90))
+			})
+		})
+		Convey("list projects with statistics", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Projects{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			stats := true
+			cl.Projects(&ListProjectsOptions{Statistics: &stats}, nil)
+			Convey("the statistics must be requested", func() {
+				So(h.get("statistics"), ShouldEqual, "true")
+			})
+		})
+		Convey("read the languages of a project", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return map[string]float64{"Go": 80, "Shell": 20}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			l, e := cl.ProjectLanguages("1")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(h.path, ShouldEqual, "/projects/1/languages")
+				So(l["Go"], ShouldEqual, float64(80))
+			})
+		})
+		Convey("aggregate the statistics of projects", func() {
+			var h *testrq
+			h = th(func(v url.Values) (interface{}, error, int) {
+				if h.path == "/projects/1/languages" {
+					return map[string]float64{"Go": 100}, nil, 200
+				}
+				return map[string]float64{"Go": 50, "Shell": 50}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			ps := Projects{
+				{Id: 1, Statistics: &ProjectStatistics{CommitCount: 10, RepositorySize: 100, LfsObjectsSize: 5}},
+				{Id: 2, Statistics: &ProjectStatistics{CommitCount: 5, RepositorySize: 300, JobArtifactsSize: 7}},
+				{Id: 3},
+			}
+			s := ps.Statistics()
+			So(s.CommitCount, ShouldEqual, 15)
+			So(s.RepositorySize, ShouldEqual, int64(400))
+			So(s.LfsObjectsSize, ShouldEqual, int64(5))
+			So(s.JobArtifactsSize, ShouldEqual, int64(7))
+			_, e := cl.ProjectsLanguages(ps)
+			So(InvalidParam.Contains(e), ShouldBeTrue)
+			l, e := cl.ProjectsLanguages(ps[:2])
+			So(e, ShouldBeNil)
+			So(l["Go"], ShouldEqual, 62.5)
+			So(l["Shell"], ShouldEqual, 37.5)
+		})
+	})
+}
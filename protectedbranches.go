@@ -0,0 +1,196 @@
+package gl
+
+import (
+	"net/url"
+)
+
+const (
+	protectedbranches_url = "/projects/:id/protected_branches"
+	protectedbranch_url   = "/projects/:id/protected_branches/:name"
+)
+
+// An access level granted by a protection rule. Only one of AccessLevel,
+// UserId or GroupId is set.
+type ProtectionAccess struct {
+	Id          int         `json:"id,omitempty"`
+	AccessLevel AccessLevel `json:"access_level,omitempty"`
+	Description string      `json:"access_level_description,omitempty"`
+	UserId      *int        `json:"user_id,omitempty"`
+	GroupId     *int        `json:"group_id,omitempty"`
+}
+
+type ProtectedBranch struct {
+	Id                        int                `json:"id,omitempty"`
+	Name                      string             `json:"name,omitempty"`
+	PushAccessLevels          []ProtectionAccess `json:"push_access_levels,omitempty"`
+	MergeAccessLevels         []ProtectionAccess `json:"merge_access_levels,omitempty"`
+	UnprotectAccessLevels     []ProtectionAccess `json:"unprotect_access_levels,omitempty"`
+	AllowForcePush            bool               `json:"allow_force_push,omitempty"`
+	CodeOwnerApprovalRequired bool               `json:"code_owner_approval_required,omitempty"`
+}
+type ProtectedBranches []ProtectedBranch
+
+// A rule which allows a user, a group or an access level to push, merge
+// or unprotect. When updating a protection, an existing rule is
+// referenced by its Id and removed by setting Destroy.
+type ProtectionRule struct {
+	Id          *int
+	UserId      *int
+	GroupId     *int
+	AccessLevel *AccessLevel
+	Destroy     bool
+}
+
+// the json encoding of a rule, gitlab expects every rule as a separate
+// object of the array
+type ruleJSON struct {
+	Id          *int         `json:"id,omitempty"`
+	UserId      *int         `json:"user_id,omitempty"`
+	GroupId     *int         `json:"group_id,omitempty"`
+	AccessLevel *AccessLevel `json:"access_level,omitempty"`
+	Destroy     bool         `json:"_destroy,omitempty"`
+}
+
+func encodeRules(rules []ProtectionRule) []ruleJSON {
+	var res []ruleJSON
+	for _, r := range rules {
+		var j ruleJSON
+		switch {
+		case r.Id != nil:
+			j.Id, j.Destroy = r.Id, r.Destroy
+		case r.UserId != nil:
+			j.UserId = r.UserId
+		case r.GroupId != nil:
+			j.GroupId = r.GroupId
+		case r.AccessLevel != nil:
+			j.AccessLevel = r.AccessLevel
+		default:
+			continue
+		}
+		res = append(res, j)
+	}
+	return res
+}
+
+// ProtectBranchOptions contains the settings of a protected branch. Only
+// the non-nil values are sent to gitlab.
+type ProtectBranchOptions struct {
+	PushAccess                *AccessLevel
+	MergeAccess               *AccessLevel
+	UnprotectAccess           *AccessLevel
+	AllowForcePush            *bool
+	CodeOwnerApprovalRequired *bool
+	AllowedToPush             []ProtectionRule
+	AllowedToMerge            []ProtectionRule
+	AllowedToUnprotect        []ProtectionRule
+}
+
+type protectBranch struct {
+	Name                      string       `json:"name,omitempty"`
+	PushAccessLevel           *AccessLevel `json:"push_access_level,omitempty"`
+	MergeAccessLevel          *AccessLevel `json:"merge_access_level,omitempty"`
+	UnprotectAccessLevel      *AccessLevel `json:"unprotect_access_level,omitempty"`
+	AllowForcePush            *bool        `json:"allow_force_push,omitempty"`
+	CodeOwnerApprovalRequired *bool        `json:"code_owner_approval_required,omitempty"`
+	AllowedToPush             []ruleJSON   `json:"allowed_to_push,omitempty"`
+	AllowedToMerge            []ruleJSON   `json:"allowed_to_merge,omitempty"`
+	AllowedToUnprotect        []ruleJSON   `json:"allowed_to_unprotect,omitempty"`
+}
+
+func (o *ProtectBranchOptions) body(name string, update bool) *protectBranch {
+	b := &protectBranch{Name: name}
+	if o == nil {
+		return b
+	}
+	push, merge, unprotect := o.AllowedToPush, o.AllowedToMerge, o.AllowedToUnprotect
+	if update {
+		// an update only knows rules, so the access levels are added
+		// as additional rules
+		push = appendLevel(push, o.PushAccess)
+		merge = appendLevel(merge, o.MergeAccess)
+		unprotect = appendLevel(unprotect, o.UnprotectAccess)
+	} else {
+		b.PushAccessLevel = o.PushAccess
+		b.MergeAccessLevel = o.MergeAccess
+		b.UnprotectAccessLevel = o.UnprotectAccess
+	}
+	b.AllowForcePush = o.AllowForcePush
+	b.CodeOwnerApprovalRequired = o.CodeOwnerApprovalRequired
+	b.AllowedToPush = encodeRules(push)
+	b.AllowedToMerge = encodeRules(merge)
+	b.AllowedToUnprotect = encodeRules(unprotect)
+	return b
+}
+
+func appendLevel(rules []ProtectionRule, level *AccessLevel) []ProtectionRule {
+	if level == nil {
+		return rules
+	}
+	return append(rules, ProtectionRule{AccessLevel: level})
+}
+
+// Needs the v4 api.
+func (g *Client) ProtectedBranches(id string, search *string, pg *Page) (ProtectedBranches, *Pagination, error) {
+	var r ProtectedBranches
+	u := expandUrl(protectedbranches_url, map[string]interface{}{":id": id})
+	vals := make(url.Values)
+	addString(vals, "search", search)
+	pager, e := g.get(u, vals, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) AllProtectedBranches(id string, search *string) (ProtectedBranches, error) {
+	var r ProtectedBranches
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.ProtectedBranches(id, search, pg)
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Returns the protection of the branch. The name can also be a wildcard
+// like "release/*". Needs the v4 api.
+func (g *Client) ProtectedBranch(id string, name string) (*ProtectedBranch, error) {
+	u := expandUrl(protectedbranch_url, map[string]interface{}{":id": id, ":name": name})
+	var b ProtectedBranch
+	_, e := g.get(u, nil, nil, &b)
+	if e != nil {
+		return nil, e
+	}
+	return &b, nil
+}
+
+// Protects the branches matching name, which can be a branch name or a
+// wildcard like "release/*". Needs the v4 api.
+func (g *Client) CreateProtectedBranch(id string, name string, opts *ProtectBranchOptions) (*ProtectedBranch, error) {
+	u := expandUrl(protectedbranches_url, map[string]interface{}{":id": id})
+	var b ProtectedBranch
+	e := g.sendJSON("POST", u, opts.body(name, false), &b)
+	if e != nil {
+		return nil, e
+	}
+	return &b, nil
+}
+
+// Changes an existing protection in place. The access levels of the
+// options are added as rules to the existing ones. Needs the v4 api.
+func (g *Client) UpdateProtectedBranch(id string, name string, opts *ProtectBranchOptions) (*ProtectedBranch, error) {
+	u := expandUrl(protectedbranch_url, map[string]interface{}{":id": id, ":name": name})
+	var b ProtectedBranch
+	e := g.sendJSON("PATCH", u, opts.body("", true), &b)
+	if e != nil {
+		return nil, e
+	}
+	return &b, nil
+}
+
+// Needs the v4 api.
+func (g *Client) DeleteProtectedBranch(id string, name string) error {
+	u := expandUrl(protectedbranch_url, map[string]interface{}{":id": id, ":name": name})
+	return g.delete(u, nil, nil)
+}
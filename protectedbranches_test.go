@@ -0,0 +1,98 @@
+package gl
+
+import (
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestProtectedBranches(t *testing.T) {
+	Convey("Protected branch functions", t, func() {
+		Convey("List the protected branches", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return ProtectedBranches{ProtectedBranch{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			search := "release"
+			cl.AllProtectedBranches("1", &search)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/protected_branches")
+				So(h.get("search"), ShouldEqual, search)
+			})
+		})
+		Convey("Protect branches with a wildcard", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProtectedBranch{}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			push := Master
+			merge := Developer
+			uid := 5
+			cl.CreateProtectedBranch("1", "release/*", &ProtectBranchOptions{
+				PushAccess:     &push,
+				MergeAccess:    &merge,
+				AllowedToPush:  []ProtectionRule{ProtectionRule{UserId: &uid}},
+				AllowForcePush: new(bool),
+			})
+			var b map[string]interface{}
+			json.Unmarshal(h.body, &b)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/protected_branches")
+				So(b["name"], ShouldEqual, "release/*")
+				So(b["push_access_level"], ShouldEqual, float64(40))
+				So(b["merge_access_level"], ShouldEqual, float64(30))
+				So(b["allowed_to_push"], ShouldResemble, []interface{}{map[string]interface{}{"user_id": float64(5)}})
+				So(b["allow_force_push"], ShouldEqual, false)
+				So(b["unprotect_access_level"], ShouldBeNil)
+				So(b["code_owner_approval_required"], ShouldBeNil)
+			})
+		})
+		Convey("Update a protection in place", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProtectedBranch{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			merge := Master
+			rid := 12
+			uid := 7
+			approval := true
+			cl.UpdateProtectedBranch("1", "release/*", &ProtectBranchOptions{
+				MergeAccess:               &merge,
+				AllowedToMerge:            []ProtectionRule{ProtectionRule{Id: &rid, Destroy: true}, ProtectionRule{UserId: &uid}},
+				CodeOwnerApprovalRequired: &approval,
+			})
+			var b map[string]interface{}
+			json.Unmarshal(h.body, &b)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "PATCH")
+				So(h.rawpath, ShouldEqual, "/projects/1/protected_branches/release%2F%2A")
+				So(b["allowed_to_merge"], ShouldResemble, []interface{}{
+					map[string]interface{}{"id": float64(12), "_destroy": true},
+					map[string]interface{}{"user_id": float64(7)},
+					map[string]interface{}{"access_level": float64(40)},
+				})
+				So(b["code_owner_approval_required"], ShouldEqual, true)
+				So(b["merge_access_level"], ShouldBeNil)
+				So(b["name"], ShouldBeNil)
+			})
+		})
+		Convey("Unprotect a branch", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 204
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.DeleteProtectedBranch("1", "master")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, "/projects/1/protected_branches/master")
+			})
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package gl
+
+const (
+	protectedtags_url = "/projects/:id/protected_tags"
+	protectedtag_url  = "/projects/:id/protected_tags/:name"
+)
+
+type ProtectedTag struct {
+	Name               string             `json:"name,omitempty"`
+	CreateAccessLevels []ProtectionAccess `json:"create_access_levels,omitempty"`
+}
+type ProtectedTags []ProtectedTag
+
+type protectTag struct {
+	Name              string       `json:"name"`
+	CreateAccessLevel *AccessLevel `json:"create_access_level,omitempty"`
+	AllowedToCreate   []ruleJSON   `json:"allowed_to_create,omitempty"`
+}
+
+// Needs the v4 api.
+func (g *Client) ProtectedTags(id string, pg *Page) (ProtectedTags, *Pagination, error) {
+	var r ProtectedTags
+	u := expandUrl(protectedtags_url, map[string]interface{}{":id": id})
+	pager, e := g.get(u, nil, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) AllProtectedTags(id string) (ProtectedTags, error) {
+	var r ProtectedTags
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.ProtectedTags(id, pg)
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Needs the v4 api.
+func (g *Client) ProtectedTag(id string, name string) (*ProtectedTag, error) {
+	u := expandUrl(protectedtag_url, map[string]interface{}{":id": id, ":name": name})
+	var t ProtectedTag
+	_, e := g.get(u, nil, nil, &t)
+	if e != nil {
+		return nil, e
+	}
+	return &t, nil
+}
+
+// Protects the tags matching name, which can be a tag name or a wildcard
+// like "v*". Only the given access level and the allowed users or groups
+// can create matching tags. Needs the v4 api.
+func (g *Client) ProtectTag(id string, name string, createAccess *AccessLevel, allowed []ProtectionRule) (*ProtectedTag, error) {
+	u := expandUrl(protectedtags_url, map[string]interface{}{":id": id})
+	body := protectTag{Name: name, CreateAccessLevel: createAccess, AllowedToCreate: encodeRules(allowed)}
+	var t ProtectedTag
+	e := g.sendJSON("POST", u, &body, &t)
+	if e != nil {
+		return nil, e
+	}
+	return &t, nil
+}
+
+// Needs the v4 api.
+func (g *Client) UnprotectTag(id string, name string) error {
+	u := expandUrl(protectedtag_url, map[string]interface{}{":id": id, ":name": name})
+	return g.delete(u, nil, nil)
+}
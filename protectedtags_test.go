@@ -0,0 +1,59 @@
+package gl
+
+import (
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestProtectedTags(t *testing.T) {
+	Convey("Protected tag functions", t, func() {
+		Convey("List the protected tags", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return ProtectedTags{ProtectedTag{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.AllProtectedTags("1")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/protected_tags")
+			})
+		})
+		Convey("Protect tags with a wildcard", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &ProtectedTag{}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			level := Master
+			gid, uid := 9, 3
+			cl.ProtectTag("1", "v*", &level, []ProtectionRule{ProtectionRule{GroupId: &gid}, ProtectionRule{UserId: &uid}})
+			var b map[string]interface{}
+			json.Unmarshal(h.body, &b)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/protected_tags")
+				So(b["name"], ShouldEqual, "v*")
+				So(b["create_access_level"], ShouldEqual, float64(40))
+				So(b["allowed_to_create"], ShouldResemble, []interface{}{
+					map[string]interface{}{"group_id": float64(9)},
+					map[string]interface{}{"user_id": float64(3)},
+				})
+			})
+		})
+		Convey("Unprotect a tag", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 204
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.UnprotectTag("1", "v*")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, "/projects/1/protected_tags/v*")
+			})
+		})
+	})
+}
@@ -0,0 +1,286 @@
+package gl
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type ReleaseLinkType string
+
+const (
+	OtherLink   = ReleaseLinkType("other")
+	RunbookLink = ReleaseLinkType("runbook")
+	ImageLink   = ReleaseLinkType("image")
+	PackageLink = ReleaseLinkType("package")
+)
+
+const (
+	releases_url     = "/projects/:id/releases"
+	release_url      = "/projects/:id/releases/:tag_name"
+	releaselinks_url = "/projects/:id/releases/:tag_name/assets/links"
+	releaselink_url  = "/projects/:id/releases/:tag_name/assets/links/:link_id"
+)
+
+// An asset link of a release.
+type ReleaseLink struct {
+	Id             int             `json:"id,omitempty"`
+	Name           string          `json:"name,omitempty"`
+	Url            string          `json:"url,omitempty"`
+	DirectAssetUrl string          `json:"direct_asset_url,omitempty"`
+	External       bool            `json:"external,omitempty"`
+	LinkType       ReleaseLinkType `json:"link_type,omitempty"`
+}
+type ReleaseLinks []ReleaseLink
+
+type ReleaseSource struct {
+	Format string `json:"format,omitempty"`
+	Url    string `json:"url,omitempty"`
+}
+
+type ReleaseAssets struct {
+	Count   int             `json:"count,omitempty"`
+	Sources []ReleaseSource `json:"sources,omitempty"`
+	Links   ReleaseLinks    `json:"links,omitempty"`
+}
+
+type Release struct {
+	TagName         string         `json:"tag_name,omitempty"`
+	Name            string         `json:"name,omitempty"`
+	Description     string         `json:"description,omitempty"`
+	DescriptionHtml string         `json:"description_html,omitempty"`
+	CreatedAt       *time.Time     `json:"created_at,omitempty"`
+	ReleasedAt      *time.Time     `json:"released_at,omitempty"`
+	Author          *User          `json:"author,omitempty"`
+	Commit          *Commit        `json:"commit,omitempty"`
+	Milestones      Milestones     `json:"milestones,omitempty"`
+	Assets          *ReleaseAssets `json:"assets,omitempty"`
+}
+type Releases []Release
+
+// ReleaseOptions contains the attributes of a release. Only the non-nil
+// values are sent to gitlab. Ref is used to create the tag if it does not
+// exist, Ref and Links are only used when a release is created.
+type ReleaseOptions struct {
+	Name        *string
+	Description *string
+	Ref         *string
+	Milestones  []string
+	ReleasedAt  *time.Time
+	Links       []ReleaseLink
+}
+
+func (o *ReleaseOptions) values() url.Values {
+	vals := make(url.Values)
+	if o == nil {
+		return vals
+	}
+	addString(vals, "name", o.Name)
+	addString(vals, "description", o.Description)
+	for _, m := range o.Milestones {
+		vals.Add("milestones[]", m)
+	}
+	addTime(vals, "released_at", o.ReleasedAt)
+	return vals
+}
+
+// a new release is sent as json, the links are an array of objects
+// which cannot be encoded as form values.
+type createRelease struct {
+	TagName     string         `json:"tag_name"`
+	Name        *string        `json:"name,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	Ref         *string        `json:"ref,omitempty"`
+	Milestones  []string       `json:"milestones,omitempty"`
+	ReleasedAt  *time.Time     `json:"released_at,omitempty"`
+	Assets      *releaseAssets `json:"assets,omitempty"`
+}
+
+type releaseAssets struct {
+	Links []releaseLinkJSON `json:"links"`
+}
+
+type releaseLinkJSON struct {
+	Name     string          `json:"name"`
+	Url      string          `json:"url"`
+	LinkType ReleaseLinkType `json:"link_type,omitempty"`
+}
+
+func (o *ReleaseOptions) create(tag string) *createRelease {
+	c := &createRelease{TagName: tag}
+	if o == nil {
+		return c
+	}
+	c.Name, c.Description, c.Ref = o.Name, o.Description, o.Ref
+	c.Milestones, c.ReleasedAt = o.Milestones, o.ReleasedAt
+	if len(o.Links) > 0 {
+		c.Assets = &releaseAssets{}
+		for _, l := range o.Links {
+			c.Assets.Links = append(c.Assets.Links, releaseLinkJSON{Name: l.Name, Url: l.Url, LinkType: l.LinkType})
+		}
+	}
+	return c
+}
+
+// Needs the v4 api.
+func (g *Client) Releases(id string, pg *Page) (Releases, *Pagination, error) {
+	var r Releases
+	u := expandUrl(releases_url, map[string]interface{}{":id": id})
+	pager, e := g.get(u, nil, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) AllReleases(id string) (Releases, error) {
+	var r Releases
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.Releases(id, pg)
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Needs the v4 api.
+func (g *Client) Release(id string, tag string) (*Release, error) {
+	u := expandUrl(release_url, map[string]interface{}{":id": id, ":tag_name": tag})
+	var r Release
+	_, e := g.get(u, nil, nil, &r)
+	if e != nil {
+		return nil, e
+	}
+	return &r, nil
+}
+
+// Needs the v4 api.
+func (g *Client) CreateRelease(id string, tag string, opts *ReleaseOptions) (*Release, error) {
+	u := expandUrl(releases_url, map[string]interface{}{":id": id})
+	var r Release
+	e := g.sendJSON("POST", u, opts.create(tag), &r)
+	if e != nil {
+		return nil, e
+	}
+	return &r, nil
+}
+
+// Needs the v4 api.
+func (g *Client) UpdateRelease(id string, tag string, opts *ReleaseOptions) (*Release, error) {
+	u := expandUrl(release_url, map[string]interface{}{":id": id, ":tag_name": tag})
+	var r Release
+	e := g.put(u, opts.values(), &r)
+	if e != nil {
+		return nil, e
+	}
+	return &r, nil
+}
+
+// Deletes the release, the tag of the release is not deleted.
+// Needs the v4 api.
+func (g *Client) DeleteRelease(id string, tag string) error {
+	u := expandUrl(release_url, map[string]interface{}{":id": id, ":tag_name": tag})
+	return g.delete(u, nil, nil)
+}
+
+// Needs the v4 api.
+func (g *Client) ReleaseLinks(id string, tag string, pg *Page) (ReleaseLinks, *Pagination, error) {
+	var r ReleaseLinks
+	u := expandUrl(releaselinks_url, map[string]interface{}{":id": id, ":tag_name": tag})
+	pager, e := g.get(u, nil, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) AllReleaseLinks(id string, tag string) (ReleaseLinks, error) {
+	var r ReleaseLinks
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.ReleaseLinks(id, tag, pg)
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Needs the v4 api.
+func (g *Client) ReleaseLink(id string, tag string, lid int) (*ReleaseLink, error) {
+	u := expandUrl(releaselink_url, map[string]interface{}{":id": id, ":tag_name": tag, ":link_id": lid})
+	var l ReleaseLink
+	_, e := g.get(u, nil, nil, &l)
+	if e != nil {
+		return nil, e
+	}
+	return &l, nil
+}
+
+// Needs the v4 api.
+func (g *Client) CreateReleaseLink(id string, tag string, name, lurl string, linkType *ReleaseLinkType) (*ReleaseLink, error) {
+	u := expandUrl(releaselinks_url, map[string]interface{}{":id": id, ":tag_name": tag})
+	vals := make(url.Values)
+	vals.Set("name", name)
+	vals.Set("url", lurl)
+	if linkType != nil {
+		vals.Set("link_type", string(*linkType))
+	}
+	var l ReleaseLink
+	e := g.post(u, vals, &l)
+	if e != nil {
+		return nil, e
+	}
+	return &l, nil
+}
+
+// Needs the v4 api.
+func (g *Client) UpdateReleaseLink(id string, tag string, lid int, name, lurl *string, linkType *ReleaseLinkType) (*ReleaseLink, error) {
+	u := expandUrl(releaselink_url, map[string]interface{}{":id": id, ":tag_name": tag, ":link_id": lid})
+	vals := make(url.Values)
+	addString(vals, "name", name)
+	addString(vals, "url", lurl)
+	if linkType != nil {
+		vals.Set("link_type", string(*linkType))
+	}
+	var l ReleaseLink
+	e := g.put(u, vals, &l)
+	if e != nil {
+		return nil, e
+	}
+	return &l, nil
+}
+
+// Needs the v4 api.
+func (g *Client) DeleteReleaseLink(id string, tag string, lid int) error {
+	u := expandUrl(releaselink_url, map[string]interface{}{":id": id, ":tag_name": tag, ":link_id": lid})
+	return g.delete(u, nil, nil)
+}
+
+// Uploads the content as a file of the project and attaches it as an
+// asset link with the given filename to the release. Needs the v4 api.
+func (g *Client) UploadReleaseAsset(id string, tag string, filename string, linkType *ReleaseLinkType, content io.Reader) (*ReleaseLink, error) {
+	up, e := g.UploadFile(id, filename, content)
+	if e != nil {
+		return nil, e
+	}
+	lurl, e := g.uploadUrl(id, up)
+	if e != nil {
+		return nil, e
+	}
+	return g.CreateReleaseLink(id, tag, filename, lurl, linkType)
+}
+
+// the absolute url of an uploaded file. Older gitlab versions only return
+// the url relative to the project.
+func (g *Client) uploadUrl(id string, up *ProjectUpload) (string, error) {
+	if up.FullPath != "" {
+		return strings.TrimSuffix(g.hostURL.String(), "/") + up.FullPath, nil
+	}
+	p, e := g.Project(id)
+	if e != nil {
+		return "", e
+	}
+	return p.WebUrl + up.Url, nil
+}
@@ -0,0 +1,114 @@
+package gl
+
+import (
+	"bytes"
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestReleases(t *testing.T) {
+	Convey("Release functions", t, func() {
+		Convey("List all releases", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Releases{Release{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.AllReleases("1")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/releases")
+			})
+		})
+		Convey("Create a release with milestones and links", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Release{}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			name := "Version 1.0"
+			ref := "master"
+			released := time.Date(2015, time.March, 1, 12, 0, 0, 0, time.UTC)
+			cl.CreateRelease("1", "v1.0", &ReleaseOptions{
+				Name:       &name,
+				Ref:        &ref,
+				Milestones: []string{"1.0", "1.0-rc"},
+				ReleasedAt: &released,
+				Links: []ReleaseLink{
+					ReleaseLink{Name: "binary", Url: "https://dl/bin", LinkType: PackageLink},
+					ReleaseLink{Name: "a", Url: "https://dl/a"},
+					ReleaseLink{Name: "b", Url: "https://dl/b"},
+				},
+			})
+			var b createRelease
+			json.Unmarshal(h.body, &b)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/releases")
+				So(b.TagName, ShouldEqual, "v1.0")
+				So(*b.Name, ShouldEqual, name)
+				So(*b.Ref, ShouldEqual, ref)
+				So(b.Milestones, ShouldResemble, []string{"1.0", "1.0-rc"})
+				So(b.ReleasedAt.Equal(released), ShouldBeTrue)
+				So(b.Description, ShouldBeNil)
+				So(b.Assets.Links, ShouldResemble, []releaseLinkJSON{
+					{Name: "binary", Url: "https://dl/bin", LinkType: PackageLink},
+					{Name: "a", Url: "https://dl/a"},
+					{Name: "b", Url: "https://dl/b"},
+				})
+			})
+		})
+		Convey("Update a release", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Release{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			desc := "notes"
+			ref := "ignored"
+			cl.UpdateRelease("1", "v1.0", &ReleaseOptions{Description: &desc, Ref: &ref})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "PUT")
+				So(h.path, ShouldEqual, "/projects/1/releases/v1.0")
+				So(h.get("description"), ShouldEqual, desc)
+				So(h.values, hasnot, "ref", "name")
+			})
+		})
+		Convey("Upload a file and attach it to a release", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				if len(v) == 0 {
+					return &ProjectUpload{Url: "/uploads/abc/tool.zip", FullPath: "/group/project/uploads/abc/tool.zip"}, nil, 201
+				}
+				return &ReleaseLink{Id: 3}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			lt := PackageLink
+			l, err := cl.UploadReleaseAsset("1", "v1.0", "tool.zip", &lt, bytes.NewBufferString("zip"))
+			Convey("the link must point to the uploaded file", func() {
+				So(err, ShouldBeNil)
+				So(l.Id, ShouldEqual, 3)
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/releases/v1.0/assets/links")
+				So(h.get("name"), ShouldEqual, "tool.zip")
+				So(h.get("url"), ShouldEqual, srv.URL+"/group/project/uploads/abc/tool.zip")
+				So(h.get("link_type"), ShouldEqual, "package")
+			})
+		})
+		Convey("Delete a release link", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 204
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.DeleteReleaseLink("1", "v1.0", 3)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, "/projects/1/releases/v1.0/assets/links/3")
+			})
+		})
+	})
+}
@@ -0,0 +1,267 @@
+package gl
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RepoFS is a read only file system view of a repository at a given ref.
+// Directories are listed lazily with the tree endpoint, the content of
+// the blobs is cached once it was read. RepoFS implements fs.ReadDirFS,
+// fs.ReadFileFS and fs.StatFS, so it can be used with fs.WalkDir,
+// template.ParseFS and friends. The tree endpoint does not return the
+// size of the blobs, so the size of a file is 0 until its content was
+// read.
+type RepoFS struct {
+	client *Client
+	id     string
+	ref    string
+
+	lock  sync.Mutex
+	dirs  map[string][]RepositoryEntry
+	blobs map[string][]byte
+}
+
+// Returns a file system view of the repository of the project at ref,
+// which can be a branch, a tag or a commit sha. Needs the v4 api.
+func (g *Client) RepoFS(id, ref string) *RepoFS {
+	return &RepoFS{
+		client: g,
+		id:     id,
+		ref:    ref,
+		dirs:   make(map[string][]RepositoryEntry),
+		blobs:  make(map[string][]byte),
+	}
+}
+
+// the entries of the directory, name must be a valid and existing path
+// The lock only guards the caches, it is not held while fetching, so a
+// slow download does not block other calls. If two calls fetch the same
+// entry, the first result is kept.
+func (r *RepoFS) list(name string) ([]RepositoryEntry, error) {
+	r.lock.Lock()
+	ents, ok := r.dirs[name]
+	r.lock.Unlock()
+	if ok {
+		return ents, nil
+	}
+	var p *string
+	if name != "." {
+		p = &name
+	}
+	ents, err := r.client.AllRepoTree(r.id, p, &r.ref, false)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ents, func(i, j int) bool { return ents[i].Name < ents[j].Name })
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if cached, ok := r.dirs[name]; ok {
+		return cached, nil
+	}
+	r.dirs[name] = ents
+	return ents, nil
+}
+
+func (r *RepoFS) blob(sha string) ([]byte, error) {
+	r.lock.Lock()
+	b, ok := r.blobs[sha]
+	r.lock.Unlock()
+	if ok {
+		return b, nil
+	}
+	b, err := r.client.RawBlobContent(r.id, sha)
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if cached, ok := r.blobs[sha]; ok {
+		return cached, nil
+	}
+	r.blobs[sha] = b
+	return b, nil
+}
+
+// the size of the blob if its content is cached
+func (r *RepoFS) blobSize(sha string) (int64, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	b, ok := r.blobs[sha]
+	return int64(len(b)), ok
+}
+
+// lookup returns the entry with the given name, the root directory is
+// returned as an entry without id.
+func (r *RepoFS) lookup(op, name string) (*RepositoryEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &RepositoryEntry{Name: ".", Type: "tree", Mode: "040000"}, nil
+	}
+	dir, base := path.Split(name)
+	if dir == "" {
+		dir = "."
+	} else {
+		dir = dir[:len(dir)-1]
+		parent, err := r.lookup(op, dir)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Type != "tree" {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	ents, err := r.list(dir)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	for i := range ents {
+		if ents[i].Name == base {
+			return &ents[i], nil
+		}
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (r *RepoFS) Open(name string) (fs.File, error) {
+	e, err := r.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := &repoFileInfo{fs: r, entry: *e}
+	if e.Type == "tree" {
+		return &repoDir{fs: r, name: name, info: info}, nil
+	}
+	b, err := r.blob(e.Id)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &repoFile{info: info, Reader: bytes.NewReader(b)}, nil
+}
+
+func (r *RepoFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := r.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Type != "tree" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	ents, err := r.list(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	res := make([]fs.DirEntry, len(ents))
+	for i := range ents {
+		res[i] = &repoFileInfo{fs: r, entry: ents[i]}
+	}
+	return res, nil
+}
+
+func (r *RepoFS) ReadFile(name string) ([]byte, error) {
+	e, err := r.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Type == "tree" {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	b, err := r.blob(e.Id)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	// the cached content must not be changed by the caller
+	return append([]byte(nil), b...), nil
+}
+
+func (r *RepoFS) Stat(name string) (fs.FileInfo, error) {
+	e, err := r.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return &repoFileInfo{fs: r, entry: *e}, nil
+}
+
+// repoFileInfo implements fs.FileInfo and fs.DirEntry for an entry of
+// the tree. The size of a blob is only known after its content was
+// fetched, Size does not fetch it and returns 0 before.
+type repoFileInfo struct {
+	fs    *RepoFS
+	entry RepositoryEntry
+}
+
+func (i *repoFileInfo) Name() string { return i.entry.Name }
+func (i *repoFileInfo) Size() int64 {
+	if i.entry.Type != "blob" {
+		return 0
+	}
+	size, _ := i.fs.blobSize(i.entry.Id)
+	return size
+}
+func (i *repoFileInfo) Mode() fs.FileMode {
+	m, _ := strconv.ParseUint(i.entry.Mode, 8, 32)
+	switch {
+	case i.entry.Type == "tree":
+		return fs.ModeDir | 0555
+	case i.entry.Type == "commit":
+		// a submodule
+		return fs.ModeIrregular
+	case m&0170000 == 0120000:
+		return fs.ModeSymlink | 0444
+	case m&0111 != 0:
+		return 0555
+	}
+	return 0444
+}
+func (i *repoFileInfo) ModTime() time.Time         { return time.Time{} }
+func (i *repoFileInfo) IsDir() bool                { return i.entry.Type == "tree" }
+func (i *repoFileInfo) Sys() interface{}           { return &i.entry }
+func (i *repoFileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i *repoFileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+type repoFile struct {
+	*bytes.Reader
+	info *repoFileInfo
+}
+
+func (f *repoFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *repoFile) Close() error               { return nil }
+
+type repoDir struct {
+	fs     *RepoFS
+	name   string
+	info   *repoFileInfo
+	offset int
+}
+
+func (d *repoDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *repoDir) Close() error               { return nil }
+func (d *repoDir) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *repoDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	ents, err := d.fs.ReadDir(d.name)
+	if err != nil {
+		return nil, err
+	}
+	ents = ents[d.offset:]
+	if n > 0 {
+		if len(ents) == 0 {
+			return nil, io.EOF
+		}
+		if n < len(ents) {
+			ents = ents[:n]
+		}
+	}
+	d.offset += len(ents)
+	return ents, nil
+}
@@ -0,0 +1,129 @@
+package gl
+
+import (
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/fs"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRepoFS(t *testing.T) {
+	Convey("repository file system", t, func() {
+		trees := map[string][]RepositoryEntry{
+			"": []RepositoryEntry{
+				{Id: "t1", Name: "docs", Type: "tree", Mode: "040000"},
+				{Id: "b1", Name: "README.md", Type: "blob", Mode: "100644"},
+				{Id: "b2", Name: "build.sh", Type: "blob", Mode: "100755"},
+			},
+			"docs": []RepositoryEntry{
+				{Id: "b3", Name: "index.md", Type: "blob", Mode: "100644"},
+			},
+		}
+		blobs := map[string]string{"b1": "# readme\n", "b2": "#!/bin/sh\n", "b3": "docs\n"}
+		var entered, release chan bool
+		treecalls, blobcalls := 0, 0
+		ref := ""
+		var h *testrq
+		h = thp(func(v url.Values) (interface{}, error, int) {
+			if strings.HasSuffix(h.path, "/repository/tree") {
+				treecalls++
+				ref = v.Get("ref")
+				ents, ok := trees[v.Get("path")]
+				if !ok {
+					return nil, nil, 404
+				}
+				b, _ := json.Marshal(ents)
+				return b, nil, 200
+			}
+			blobcalls++
+			if release != nil {
+				entered <- true
+				<-release
+			}
+			return []byte(blobs[h.path[strings.LastIndex(h.path, "/")+1:]]), nil, 200
+		})
+		srv, cl := StubHandler(h)
+		defer srv.Close()
+		rfs := cl.RepoFS("1", "master")
+		Convey("the file system must pass the fstest checks", func() {
+			So(fstest.TestFS(rfs, "README.md", "build.sh", "docs/index.md"), ShouldBeNil)
+		})
+		Convey("the directories are listed once and the blobs are cached", func() {
+			treecalls, blobcalls = 0, 0
+			rfs = cl.RepoFS("1", "master")
+			var files []string
+			fs.WalkDir(rfs, ".", func(p string, d fs.DirEntry, err error) error {
+				if !d.IsDir() {
+					files = append(files, p)
+				}
+				return nil
+			})
+			So(files, ShouldResemble, []string{"README.md", "build.sh", "docs/index.md"})
+			b, err := fs.ReadFile(rfs, "docs/index.md")
+			So(err, ShouldBeNil)
+			So(string(b), ShouldEqual, "docs\n")
+			fs.ReadFile(rfs, "docs/index.md")
+			So(treecalls, ShouldEqual, 2)
+			So(blobcalls, ShouldEqual, 1)
+			So(ref, ShouldEqual, "master")
+		})
+		Convey("stat returns the mode of the entries", func() {
+			rfs = cl.RepoFS("1", "master")
+			blobcalls = 0
+			fi, err := rfs.Stat("build.sh")
+			So(err, ShouldBeNil)
+			So(fi.Mode(), ShouldEqual, fs.FileMode(0555))
+			So(fi.Size(), ShouldEqual, int64(0))
+			So(blobcalls, ShouldEqual, 0)
+			rfs.ReadFile("build.sh")
+			So(fi.Size(), ShouldEqual, int64(len("#!/bin/sh\n")))
+			fi, err = rfs.Stat("docs")
+			So(err, ShouldBeNil)
+			So(fi.IsDir(), ShouldBeTrue)
+		})
+		Convey("a running download does not block cached entries", func() {
+			rfs = cl.RepoFS("1", "master")
+			rfs.ReadDir("docs")
+			entered, release = make(chan bool), make(chan bool)
+			done := make(chan error)
+			go func() {
+				_, err := rfs.ReadFile("docs/index.md")
+				done <- err
+			}()
+			<-entered
+			var fi fs.FileInfo
+			stat := make(chan error)
+			go func() {
+				var err error
+				fi, err = rfs.Stat("docs/index.md")
+				stat <- err
+			}()
+			select {
+			case err := <-stat:
+				So(err, ShouldBeNil)
+				So(fi.Size(), ShouldEqual, int64(0))
+			case <-time.After(time.Second):
+				So("stat is blocked by the download", ShouldBeEmpty)
+				close(release)
+				return
+			}
+			close(release)
+			So(<-done, ShouldBeNil)
+			So(fi.Size(), ShouldEqual, int64(len("docs\n")))
+			release = nil
+		})
+		Convey("missing and invalid paths return the fs errors", func() {
+			_, err := rfs.Open("docs/missing.md")
+			So(err != nil && errors.Is(err, fs.ErrNotExist), ShouldBeTrue)
+			_, err = rfs.Open("README.md/x")
+			So(err != nil && errors.Is(err, fs.ErrNotExist), ShouldBeTrue)
+			_, err = rfs.Open("/README.md")
+			So(err != nil && errors.Is(err, fs.ErrInvalid), ShouldBeTrue)
+		})
+	})
+}
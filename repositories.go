@@ -1,24 +1,38 @@
 package gl
 
 import (
+	"encoding/json"
 	"net/url"
+	"strings"
 	"time"
+
+	"github.com/spacemonkeygo/errors/errhttp"
 )
 
 const (
 	tags_url         = "/projects/:id/repository/tags"
+	tag_url          = "/projects/:id/repository/tags/:tag_name"
+	tagrelease_url   = "/projects/:id/repository/tags/:tag_name/release"
 	tree_url         = "/projects/:id/repository/tree"
 	file_content     = "/projects/:id/repository/blobs/:sha"
 	blob_content     = "/projects/:id/repository/raw_blobs/:sha"
 	archive_url      = "/projects/:id/repository/archive"
 	compare_url      = "/projects/:id/repository/compare"
+	mergebase_url    = "/projects/:id/repository/merge_base"
 	contributors_url = "/projects/:id/repository/contributors"
 	readfile_url     = "/projects/:id/repository/files"
 	commits_url      = "/projects/:id/repository/commits"
 	commit_url       = "/projects/:id/repository/commits/:sha"
 	commitdiff_url   = "/projects/:id/repository/commits/:sha/diff"
+	cherrypick_url   = "/projects/:id/repository/commits/:sha/cherry_pick"
+	revert_url       = "/projects/:id/repository/commits/:sha/revert"
 )
 
+// CommitConflict is returned when a commit cannot be cherry picked or
+// reverted because its changes conflict with the target branch. It is a
+// gitlab error too.
+var CommitConflict = gitlabError.NewClass("commit conflict")
+
 type CommitParent struct {
 	Id string `json:"id,omitempty"`
 }
@@ -54,7 +68,16 @@ type Commit struct {
 	Authored       time.Time      `json:"authored_date,omitempty"`
 	Committed      time.Time      `json:"committed_date,omitempty"`
 	Parents        []CommitParent `json:"parents,omitempty"`
+	Stats          *CommitStats   `json:"stats,omitempty"`
+}
+
+// The changed lines of a commit, only returned if requested.
+type CommitStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+	Total     int `json:"total"`
 }
+
 type NamedCommitEx struct {
 	Name      string    `json:"name,omitempty"`
 	Commit    *CommitEx `json:"commit,omitempty"`
@@ -66,20 +89,78 @@ type namedCommit struct {
 	Protected bool    `json:"protected,omitempty"`
 }
 
+type CommitActionType string
+
+const (
+	CreateAction = CommitActionType("create")
+	DeleteAction = CommitActionType("delete")
+	MoveAction   = CommitActionType("move")
+	UpdateAction = CommitActionType("update")
+	ChmodAction  = CommitActionType("chmod")
+
+	TextEncoding   = "text"
+	Base64Encoding = "base64"
+)
+
+// One change to a file in a commit created with CreateCommit. The
+// content is plain text or base64 encoded, depending on the Encoding.
+type CommitAction struct {
+	Action          CommitActionType `json:"action"`
+	FilePath        string           `json:"file_path"`
+	PreviousPath    string           `json:"previous_path,omitempty"`
+	Content         string           `json:"content,omitempty"`
+	Encoding        string           `json:"encoding,omitempty"`
+	LastCommitId    string           `json:"last_commit_id,omitempty"`
+	ExecuteFilemode *bool            `json:"execute_filemode,omitempty"`
+}
+
+// CreateCommitOptions contains the optional attributes of a new commit.
+// If the branch does not exist, it is created from StartBranch or StartSha.
+type CreateCommitOptions struct {
+	StartBranch *string `json:"start_branch,omitempty"`
+	StartSha    *string `json:"start_sha,omitempty"`
+	AuthorEMail *string `json:"author_email,omitempty"`
+	AuthorName  *string `json:"author_name,omitempty"`
+	Force       *bool   `json:"force,omitempty"`
+}
+
+type createCommit struct {
+	Branch  string         `json:"branch"`
+	Message string         `json:"commit_message"`
+	Actions []CommitAction `json:"actions"`
+	CreateCommitOptions
+}
+
 type Branch struct {
 	NamedCommitEx
+	Merged             bool `json:"merged,omitempty"`
+	Default            bool `json:"default,omitempty"`
+	CanPush            bool `json:"can_push,omitempty"`
+	DevelopersCanPush  bool `json:"developers_can_push,omitempty"`
+	DevelopersCanMerge bool `json:"developers_can_merge,omitempty"`
 }
 type TagListEntry struct {
 	NamedCommitEx
+	Message string      `json:"message,omitempty"`
+	Release *TagRelease `json:"release,omitempty"`
 }
 type Tag struct {
 	Commit
+	Release *TagRelease `json:"release,omitempty"`
+}
+
+// The release notes of a tag.
+type TagRelease struct {
+	TagName     string `json:"tag_name,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 type RepositoryEntry struct {
 	Id   string `json:"id,omitempty"`
 	Name string `json:"name,omitempty"`
 	Type string `json:"type,omitempty"`
+	// the full path of the entry in the repository
+	Path string `json:"path,omitempty"`
 	Mode string `json:"mode,omitempty"`
 }
 
@@ -168,6 +249,30 @@ func (g *Client) UnprotectBranch(id string, branch string) (*Branch, error) {
 	return g.protectBranch(id, branch, "/unprotect")
 }
 
+func (g *Client) CreateBranch(id string, branch, ref string) (*Branch, error) {
+	var b Branch
+	u := expandUrl(branches_url, map[string]interface{}{":id": id})
+	vals := make(url.Values)
+	vals.Set("branch", branch)
+	vals.Set("ref", ref)
+	if e := g.post(u, vals, &b); e != nil {
+		return nil, e
+	}
+	return &b, nil
+}
+func (g *Client) DeleteBranch(id string, branch string) error {
+	u := expandUrl(branch_url, map[string]interface{}{":id": id, ":branch": branch})
+	return g.delete(u, nil, nil)
+}
+
+// Deletes all branches which are merged into the default branch. Protected
+// branches are not deleted. Gitlab deletes the branches asynchronously.
+// Needs the v4 api.
+func (g *Client) DeleteMergedBranches(id string) error {
+	u := expandUrl(mergedbranches_url, map[string]interface{}{":id": id})
+	return g.delete(u, nil, nil)
+}
+
 func (g *Client) Tags(pid string, pg *Page) ([]TagListEntry, *Pagination, error) {
 	var r []TagListEntry
 	u := expandUrl(tags_url, map[string]interface{}{":id": pid})
@@ -202,6 +307,34 @@ func (g *Client) CreateTag(id string, name, ref string, msg *string) (*Tag, erro
 	return &s, nil
 }
 
+func (g *Client) DeleteTag(id string, name string) error {
+	u := expandUrl(tag_url, map[string]interface{}{":id": id, ":tag_name": name})
+	return g.delete(u, nil, nil)
+}
+
+func (g *Client) CreateTagRelease(id string, name, description string) (*TagRelease, error) {
+	return g.tagRelease(true, id, name, description)
+}
+func (g *Client) UpdateTagRelease(id string, name, description string) (*TagRelease, error) {
+	return g.tagRelease(false, id, name, description)
+}
+func (g *Client) tagRelease(ispost bool, id string, name, description string) (*TagRelease, error) {
+	u := expandUrl(tagrelease_url, map[string]interface{}{":id": id, ":tag_name": name})
+	var r TagRelease
+	vals := make(url.Values)
+	vals.Set("description", description)
+	var e error
+	if ispost {
+		e = g.post(u, vals, &r)
+	} else {
+		e = g.put(u, vals, &r)
+	}
+	if e != nil {
+		return nil, e
+	}
+	return &r, nil
+}
+
 func (g *Client) RepoEntries(id string, path, ref *string, pg *Page) ([]RepositoryEntry, *Pagination, error) {
 	var r []RepositoryEntry
 	u := expandUrl(tree_url, map[string]interface{}{":id": id})
@@ -214,6 +347,58 @@ func (g *Client) RepoEntries(id string, path, ref *string, pg *Page) ([]Reposito
 	}
 	return r, pager, nil
 }
+
+// Lists the entries of the tree at path. If recursive is true, the
+// entries of all subdirectories are listed too. Recursive listings and
+// the full paths of the entries need the v4 api, so the ref is sent as
+// the v4 parameter ref.
+func (g *Client) RepoTree(id string, path, ref *string, recursive bool, pg *Page) ([]RepositoryEntry, *Pagination, error) {
+	var r []RepositoryEntry
+	u := expandUrl(tree_url, map[string]interface{}{":id": id})
+	vals := make(url.Values)
+	addString(vals, "path", path)
+	addString(vals, "ref", ref)
+	if recursive {
+		addBool(vals, "recursive", true)
+	}
+	pager, e := g.get(u, vals, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+func (g *Client) AllRepoTree(id string, path, ref *string, recursive bool) ([]RepositoryEntry, error) {
+	var b []RepositoryEntry
+	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.RepoTree(id, path, ref, recursive, pg)
+	}, &b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Calls fn for every entry of the tree while the pages are fetched, so
+// large trees need not be held in memory. The walk stops at the first
+// error returned by fn.
+func (g *Client) WalkRepoTree(id string, path, ref *string, recursive bool, fn func(*RepositoryEntry) error) error {
+	var pg *Page
+	for {
+		ents, pag, e := g.RepoTree(id, path, ref, recursive, pg)
+		if e != nil {
+			return e
+		}
+		for i := range ents {
+			if e := fn(&ents[i]); e != nil {
+				return e
+			}
+		}
+		if pag.NextPage == nil {
+			return nil
+		}
+		pg = pag.NextPage
+	}
+}
 func (g *Client) AllRepoEntries(id string, path, ref *string) ([]RepositoryEntry, error) {
 	var b []RepositoryEntry
 	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
@@ -253,8 +438,31 @@ func (g *Client) Archive(id string, sha *string) ([]byte, error) {
 	return buf, nil
 }
 func (g *Client) Compare(id string, from, to string) (*Comparison, error) {
+	return g.CompareWithOptions(id, from, to, nil)
+}
+
+// CompareOptions contains the optional parameters of a comparison. Only
+// the non-nil values are sent to gitlab.
+type CompareOptions struct {
+	// compare from and to directly instead of using their merge base
+	Straight *bool
+	// the id of the project of from, to compare with a fork
+	FromProjectId *int
+}
+
+func (o *CompareOptions) values() url.Values {
+	vals := make(url.Values)
+	if o == nil {
+		return vals
+	}
+	addOptBool(vals, "straight", o.Straight)
+	addInt(vals, "from_project_id", o.FromProjectId)
+	return vals
+}
+
+func (g *Client) CompareWithOptions(id string, from, to string, opts *CompareOptions) (*Comparison, error) {
 	u := expandUrl(compare_url, map[string]interface{}{":id": id})
-	v := make(url.Values)
+	v := opts.values()
 	v.Set("from", from)
 	v.Set("to", to)
 	var c Comparison
@@ -264,6 +472,24 @@ func (g *Client) Compare(id string, from, to string) (*Comparison, error) {
 	}
 	return &c, nil
 }
+
+// Returns the common ancestor of two or more refs. Needs the v4 api.
+func (g *Client) MergeBase(id string, refs ...string) (*Commit, error) {
+	if len(refs) < 2 {
+		return nil, InvalidParam.New("a merge base needs at least two refs, got %d", len(refs))
+	}
+	u := expandUrl(mergebase_url, map[string]interface{}{":id": id})
+	v := make(url.Values)
+	for _, r := range refs {
+		v.Add("refs[]", r)
+	}
+	var c Commit
+	_, e := g.get(u, v, nil, &c)
+	if e != nil {
+		return nil, e
+	}
+	return &c, nil
+}
 func (g *Client) Contributors(id string, pg *Page) ([]Contributor, *Pagination, error) {
 	var r []Contributor
 	u := expandUrl(contributors_url, map[string]interface{}{":id": id})
@@ -336,27 +562,66 @@ func (g *Client) DeleteFile(id, filepath, branch, commitmsg string) (*RepoFile,
 	return &b, nil
 }
 
-func (g *Client) Commits(id string, ref *string, pg *Page) ([]Commit, *Pagination, error) {
+// ListCommitsOptions filters the commit listing. Only the non-nil values
+// are sent to gitlab.
+type ListCommitsOptions struct {
+	RefName     *string
+	Since       *time.Time
+	Until       *time.Time
+	Path        *string
+	All         *bool
+	WithStats   *bool
+	FirstParent *bool
+}
+
+func (o *ListCommitsOptions) values() url.Values {
+	vals := make(url.Values)
+	if o != nil {
+		addString(vals, "ref_name", o.RefName)
+		addTime(vals, "since", o.Since)
+		addTime(vals, "until", o.Until)
+		addString(vals, "path", o.Path)
+		addOptBool(vals, "all", o.All)
+		addOptBool(vals, "with_stats", o.WithStats)
+		addOptBool(vals, "first_parent", o.FirstParent)
+	}
+	return vals
+}
+
+func (g *Client) Commits(id string, opts *ListCommitsOptions, pg *Page) ([]Commit, *Pagination, error) {
 	var r []Commit
 	u := expandUrl(commits_url, map[string]interface{}{":id": id})
-	vals := make(url.Values)
-	addString(vals, "ref_name", ref)
-	pager, e := g.get(u, vals, pg, &r)
+	pager, e := g.get(u, opts.values(), pg, &r)
 	if e != nil {
 		return nil, nil, e
 	}
 	return r, pager, nil
 }
-func (g *Client) AllCommits(id string, ref *string) ([]Commit, error) {
+func (g *Client) AllCommits(id string, opts *ListCommitsOptions) ([]Commit, error) {
 	var b []Commit
 	err := fetchAll(func(pg *Page) (interface{}, *Pagination, error) {
-		return g.Commits(id, ref, pg)
+		return g.Commits(id, opts, pg)
 	}, &b)
 	if err != nil {
 		return nil, err
 	}
 	return b, nil
 }
+
+// Creates one commit on the branch with all the given actions.
+func (g *Client) CreateCommit(id string, branch, message string, actions []CommitAction, opts *CreateCommitOptions) (*Commit, error) {
+	u := expandUrl(commits_url, map[string]interface{}{":id": id})
+	c := createCommit{Branch: branch, Message: message, Actions: actions}
+	if opts != nil {
+		c.CreateCommitOptions = *opts
+	}
+	var b Commit
+	e := g.sendJSON("POST", u, &c, &b)
+	if e != nil {
+		return nil, e
+	}
+	return &b, nil
+}
 func (g *Client) ReadCommit(id, sha string) (*Commit, error) {
 	var b Commit
 	u := expandUrl(commit_url, map[string]interface{}{":id": id, ":sha": sha})
@@ -375,3 +640,48 @@ func (g *Client) ReadDiff(id, sha string) (*Diff, error) {
 	}
 	return &b, nil
 }
+
+// Cherry picks the commit onto the branch and returns the new commit.
+func (g *Client) CherryPickCommit(id, sha, branch string) (*Commit, error) {
+	return g.applyCommit(cherrypick_url, "cherry-pick", id, sha, branch)
+}
+
+// Reverts the commit on the branch and returns the new commit.
+// Needs the v4 api.
+func (g *Client) RevertCommit(id, sha, branch string) (*Commit, error) {
+	return g.applyCommit(revert_url, "revert", id, sha, branch)
+}
+
+func (g *Client) applyCommit(endpoint, op, id, sha, branch string) (*Commit, error) {
+	var b Commit
+	u := expandUrl(endpoint, map[string]interface{}{":id": id, ":sha": sha})
+	vals := make(url.Values)
+	vals.Set("branch", branch)
+	e := g.post(u, vals, &b)
+	if e != nil {
+		if isConflict(e, op) {
+			return nil, CommitConflict.NewWith(e.Error(), errhttp.SetStatusCode(GetStatusCode(e, 0)), errhttp.SetErrorBody(GetErrorBody(e)))
+		}
+		return nil, e
+	}
+	return &b, nil
+}
+
+// newer versions of gitlab mark a conflict with an error code, older
+// versions only return the message.
+func isConflict(e error, op string) bool {
+	if GetStatusCode(e, 0) != 400 {
+		return false
+	}
+	var msg struct {
+		Message   string `json:"message"`
+		ErrorCode string `json:"error_code"`
+	}
+	if json.Unmarshal([]byte(GetErrorBody(e)), &msg) != nil {
+		return false
+	}
+	if msg.ErrorCode != "" {
+		return msg.ErrorCode == "conflict"
+	}
+	return strings.Contains(msg.Message, "cannot "+op+" this commit automatically")
+}
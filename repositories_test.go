@@ -1,9 +1,14 @@
 package gl
 
 import (
+	"encoding/json"
+	"fmt"
 	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestRepository(t *testing.T) {
@@ -36,6 +41,77 @@ func TestRepository(t *testing.T) {
 				So(h.get("message"), ShouldEqual, msg)
 			})
 		})
+		Convey("List repository tags with their release notes", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`[{"name":"v1.0","message":"first","release":{"tag_name":"v1.0","description":"notes"}}]`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			tags, _ := cl.AllTags("1")
+			Convey("the release must be unmarshalled", func() {
+				So(len(tags), ShouldEqual, 1)
+				So(tags[0].Name, ShouldEqual, "v1.0")
+				So(tags[0].Message, ShouldEqual, "first")
+				So(tags[0].Release.Description, ShouldEqual, "notes")
+			})
+		})
+		Convey("delete a repository tag", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 204
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.DeleteTag("1", "v1.0")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, "/projects/1/repository/tags/v1.0")
+			})
+		})
+		Convey("add and update the release notes of a tag", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &TagRelease{}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.CreateTagRelease("1", "v1.0", "notes")
+			So(h.method, ShouldEqual, "POST")
+			So(h.path, ShouldEqual, "/projects/1/repository/tags/v1.0/release")
+			So(h.get("description"), ShouldEqual, "notes")
+			cl.UpdateTagRelease("1", "v1.0", "other notes")
+			So(h.method, ShouldEqual, "PUT")
+			So(h.path, ShouldEqual, "/projects/1/repository/tags/v1.0/release")
+			So(h.get("description"), ShouldEqual, "other notes")
+		})
+		Convey("create a commit with several actions", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Commit{Id: "abc"}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			start := "master"
+			c, err := cl.CreateCommit("1", "generated", "regenerate", []CommitAction{
+				CommitAction{Action: CreateAction, FilePath: "a.go", Content: "package a"},
+				CommitAction{Action: DeleteAction, FilePath: "b.go"},
+				CommitAction{Action: MoveAction, FilePath: "d.bin", PreviousPath: "c.bin", Content: "AAE=", Encoding: Base64Encoding},
+			}, &CreateCommitOptions{StartBranch: &start})
+			Convey("the actions must be sent json encoded", func() {
+				So(err, ShouldBeNil)
+				So(c.Id, ShouldEqual, "abc")
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/repository/commits")
+				var sent map[string]interface{}
+				json.Unmarshal(h.body, &sent)
+				So(sent["branch"], ShouldEqual, "generated")
+				So(sent["commit_message"], ShouldEqual, "regenerate")
+				So(sent["start_branch"], ShouldEqual, start)
+				So(sent["author_name"], ShouldBeNil)
+				actions := sent["actions"].([]interface{})
+				So(len(actions), ShouldEqual, 3)
+				So(actions[1].(map[string]interface{})["action"], ShouldEqual, "delete")
+				So(actions[2].(map[string]interface{})["previous_path"], ShouldEqual, "c.bin")
+				So(actions[2].(map[string]interface{})["encoding"], ShouldEqual, "base64")
+			})
+		})
 		Convey("List repository entries", func() {
 			h := th(func(v url.Values) (interface{}, error, int) {
 				return []RepositoryEntry{RepositoryEntry{}}, nil, 200
@@ -194,11 +270,174 @@ func TestRepository(t *testing.T) {
 			srv, cl := StubHandler(h)
 			defer srv.Close()
 			ref := "mybranch"
-			cl.AllCommits("1", &ref)
+			cl.AllCommits("1", &ListCommitsOptions{RefName: &ref})
 			Convey("check if the request was correct", func() {
 				So(h.method, ShouldEqual, "GET")
 				So(h.path, ShouldEqual, "/projects/1/repository/commits")
 				So(h.get("ref_name"), ShouldEqual, "mybranch")
+				So(h.values, hasnot, "since", "until", "path", "all", "with_stats", "first_parent")
+			})
+		})
+		Convey("receive the commits of a path in a time window with stats", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`[{"id":"abc","stats":{"additions":3,"deletions":1,"total":4}}]`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			since := time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)
+			until := since.AddDate(0, 1, 0)
+			path := "docs"
+			stats := true
+			commits, _ := cl.AllCommits("1", &ListCommitsOptions{Since: &since, Until: &until, Path: &path, WithStats: &stats})
+			Convey("check if the request was correct", func() {
+				So(h.get("since"), ShouldEqual, "2015-01-01T00:00:00Z")
+				So(h.get("until"), ShouldEqual, "2015-02-01T00:00:00Z")
+				So(h.get("path"), ShouldEqual, path)
+				So(h.get("with_stats"), ShouldEqual, "true")
+				So(commits[0].Stats.Additions, ShouldEqual, 3)
+				So(commits[0].Stats.Deletions, ShouldEqual, 1)
+				So(commits[0].Stats.Total, ShouldEqual, 4)
+			})
+		})
+		Convey("list a tree recursively", func() {
+			h := thp(func(v url.Values) (interface{}, error, int) {
+				return []byte(`[{"id":"b1","name":"index.md","type":"blob","path":"docs/index.md","mode":"100644"}]`), nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			p, ref := "docs", "master"
+			ents, _ := cl.AllRepoTree("1", &p, &ref, true)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/repository/tree")
+				So(h.get("recursive"), ShouldEqual, "true")
+				So(h.get("path"), ShouldEqual, "docs")
+				So(h.get("ref"), ShouldEqual, "master")
+				So(h.values, hasnot, "ref_name")
+				So(ents[0].Path, ShouldEqual, "docs/index.md")
+			})
+		})
+		Convey("walk a tree page by page", func() {
+			var pages []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pg := r.URL.Query().Get("page")
+				pages = append(pages, pg)
+				if pg == "1" {
+					w.Header().Set("Link", `<http://`+r.Host+r.URL.Path+`?page=2&per_page=100>; rel="next"`)
+					w.Write([]byte(`[{"id":"b1","path":"a.txt","type":"blob"},{"id":"t1","path":"dir","type":"tree"}]`))
+					return
+				}
+				w.Write([]byte(`[{"id":"b2","path":"dir/b.txt","type":"blob"}]`))
+			}))
+			defer srv.Close()
+			cl, _ := Open(srv.URL, "")
+			var paths []string
+			e := cl.WalkRepoTree("1", nil, nil, true, func(ent *RepositoryEntry) error {
+				paths = append(paths, ent.Path)
+				return nil
+			})
+			Convey("all pages must be walked", func() {
+				So(e, ShouldBeNil)
+				So(pages, ShouldResemble, []string{"1", "2"})
+				So(paths, ShouldResemble, []string{"a.txt", "dir", "dir/b.txt"})
+			})
+		})
+		Convey("cherry pick a commit", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Commit{Id: "newsha"}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			c, e := cl.CherryPickCommit("1", "mysha", "release/1.0")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(c.Id, ShouldEqual, "newsha")
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/repository/commits/mysha/cherry_pick")
+				So(h.get("branch"), ShouldEqual, "release/1.0")
+			})
+		})
+		Convey("revert a commit with a conflict", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, fmt.Errorf(`{"message":"Sorry, we cannot revert this commit automatically.","error_code":"conflict"}`), 400
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			_, e := cl.RevertCommit("1", "mysha", "master")
+			Convey("a conflict error must be returned", func() {
+				So(h.path, ShouldEqual, "/projects/1/repository/commits/mysha/revert")
+				So(CommitConflict.Contains(e), ShouldBeTrue)
+				So(gitlabError.Contains(e), ShouldBeTrue)
+				So(GetStatusCode(e, 0), ShouldEqual, 400)
+			})
+		})
+		Convey("revert a commit with an other error", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, fmt.Errorf(`{"message":"Sorry, we cannot revert this commit automatically.","error_code":"empty"}`), 400
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			_, e := cl.RevertCommit("1", "mysha", "master")
+			Convey("it is no conflict", func() {
+				So(CommitConflict.Contains(e), ShouldBeFalse)
+				So(gitlabError.Contains(e), ShouldBeTrue)
+			})
+		})
+		Convey("cherry pick with an old gitlab", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, fmt.Errorf(`{"message":"Sorry, we cannot cherry-pick this commit automatically."}`), 400
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			_, e := cl.CherryPickCommit("1", "mysha", "master")
+			Convey("the message marks the conflict", func() {
+				So(CommitConflict.Contains(e), ShouldBeTrue)
+			})
+		})
+		Convey("compare two refs straight across forks", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Comparison{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			straight, fork := true, 42
+			cl.CompareWithOptions("1", "master", "feature", &CompareOptions{Straight: &straight, FromProjectId: &fork})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/repository/compare")
+				So(h.get("from"), ShouldEqual, "master")
+				So(h.get("to"), ShouldEqual, "feature")
+				So(h.get("straight"), ShouldEqual, "true")
+				So(h.get("from_project_id"), ShouldEqual, "42")
+			})
+		})
+		Convey("compare without options", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Comparison{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.Compare("1", "master", "feature")
+			Convey("no options must be sent", func() {
+				So(h.values, hasnot, "straight", "from_project_id")
+			})
+		})
+		Convey("find the merge base of refs", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Commit{Id: "base"}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			c, e := cl.MergeBase("1", "master", "release/1.0", "feature")
+			Convey("check if the request was correct", func() {
+				So(e, ShouldBeNil)
+				So(c.Id, ShouldEqual, "base")
+				So(h.path, ShouldEqual, "/projects/1/repository/merge_base")
+				So(h.values["refs[]"], ShouldResemble, []string{"master", "release/1.0", "feature"})
+			})
+			_, e = cl.MergeBase("1", "master")
+			Convey("one ref is not enough", func() {
+				So(InvalidParam.Contains(e), ShouldBeTrue)
 			})
 		})
 		Convey("read one commit", func() {
@@ -0,0 +1,195 @@
+package gl
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spacemonkeygo/errors"
+)
+
+var syncError = errors.NewClass("sync")
+
+// SyncOptions contains the optional parameters of SyncDirectory.
+type SyncOptions struct {
+	// the directory in the repository which mirrors the local directory,
+	// empty for the root of the repository
+	Path string
+	// glob patterns of the paths which are synced, all paths if empty.
+	// The patterns are matched against the paths relative to the synced
+	// directories, "**" matches any number of directories.
+	Include []string
+	// glob patterns of the paths which are neither changed nor deleted
+	Exclude []string
+	// only report the changes, do not commit them
+	DryRun        bool
+	CommitOptions *CreateCommitOptions
+}
+
+// A change to the repository found by SyncDirectory.
+type SyncChange struct {
+	Action CommitActionType
+	Path   string
+}
+
+type SyncReport struct {
+	Changes []SyncChange
+	// the created commit, nil for a dry run or if there are no changes
+	Commit *Commit
+}
+
+func (r *SyncReport) NoChanges() bool {
+	return len(r.Changes) == 0
+}
+
+type syncFile struct {
+	id   string
+	exec bool
+}
+
+// Mirrors the local directory into the branch, which must exist. The
+// directory Path in the repository is created if it does not exist. The
+// files are compared by their git blob ids, so only the differences
+// are committed as one commit. Files in the repository which do not
+// exist locally are deleted, symlinks and the .git directory are
+// ignored. Needs the v4 api.
+func (g *Client) SyncDirectory(id, branch, dir, message string, opts *SyncOptions) (*SyncReport, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	prefix := strings.Trim(opts.Path, "/")
+	var p *string
+	if prefix != "" {
+		p = &prefix
+	}
+	ents, e := g.AllRepoTree(id, p, &branch, true)
+	if e != nil {
+		// the directory does not exist on the branch yet
+		if GetStatusCode(e, 0) != 404 || prefix == "" {
+			return nil, e
+		}
+		ents = nil
+	}
+	remote := make(map[string]syncFile)
+	for _, ent := range ents {
+		if ent.Type != "blob" || ent.Mode == "120000" {
+			continue
+		}
+		rel := ent.Path
+		if prefix != "" {
+			rel = strings.TrimPrefix(rel, prefix+"/")
+		}
+		ok, e := opts.matches(rel)
+		if e != nil {
+			return nil, e
+		}
+		if ok {
+			remote[rel] = syncFile{id: ent.Id, exec: ent.Mode == "100755"}
+		}
+	}
+
+	report := &SyncReport{}
+	var actions []CommitAction
+	local := make(map[string]bool)
+	e = filepath.Walk(dir, func(fp string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return syncError.Wrap(err)
+		}
+		if fi.IsDir() && fi.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		r, err := filepath.Rel(dir, fp)
+		if err != nil {
+			return syncError.Wrap(err)
+		}
+		rel := filepath.ToSlash(r)
+		ok, err := opts.matches(rel)
+		if err != nil || !ok {
+			return err
+		}
+		local[rel] = true
+		content, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return syncError.Wrap(err)
+		}
+		exec := fi.Mode()&0111 != 0
+		target := path.Join(prefix, rel)
+		rf, exists := remote[rel]
+		var a *CommitAction
+		switch {
+		case !exists:
+			a = &CommitAction{Action: CreateAction, FilePath: target}
+			if exec {
+				a.ExecuteFilemode = &exec
+			}
+		case rf.id != blobId(content):
+			a = &CommitAction{Action: UpdateAction, FilePath: target}
+		}
+		if a != nil {
+			a.Content = base64.StdEncoding.EncodeToString(content)
+			a.Encoding = Base64Encoding
+			actions = append(actions, *a)
+		}
+		if exists && rf.exec != exec {
+			actions = append(actions, CommitAction{Action: ChmodAction, FilePath: target, ExecuteFilemode: &exec})
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	for rel := range remote {
+		if !local[rel] {
+			actions = append(actions, CommitAction{Action: DeleteAction, FilePath: path.Join(prefix, rel)})
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].FilePath < actions[j].FilePath })
+	for _, a := range actions {
+		report.Changes = append(report.Changes, SyncChange{Action: a.Action, Path: a.FilePath})
+	}
+	if opts.DryRun || report.NoChanges() {
+		return report, nil
+	}
+	report.Commit, e = g.CreateCommit(id, branch, message, actions, opts.CommitOptions)
+	if e != nil {
+		return nil, e
+	}
+	return report, nil
+}
+
+func (o *SyncOptions) matches(rel string) (bool, error) {
+	for _, p := range o.Exclude {
+		ok, e := matchGlob(p, rel)
+		if e != nil || ok {
+			return false, e
+		}
+	}
+	if len(o.Include) == 0 {
+		return true, nil
+	}
+	for _, p := range o.Include {
+		ok, e := matchGlob(p, rel)
+		if e != nil || ok {
+			return ok, e
+		}
+	}
+	return false, nil
+}
+
+// the id git uses for a blob with the given content
+func blobId(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
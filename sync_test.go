@@ -0,0 +1,105 @@
+package gl
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncDirectory(t *testing.T) {
+	Convey("sync a local directory into a branch", t, func() {
+		tmp, _ := ioutil.TempDir("", "gl-sync")
+		defer os.RemoveAll(tmp)
+		os.MkdirAll(filepath.Join(tmp, "docs", ".git"), 0755)
+		ioutil.WriteFile(filepath.Join(tmp, "docs", "same.md"), []byte("same"), 0644)
+		ioutil.WriteFile(filepath.Join(tmp, "docs", "changed.md"), []byte("new content"), 0644)
+		ioutil.WriteFile(filepath.Join(tmp, "docs", "build.sh"), []byte("#!/bin/sh"), 0755)
+		ioutil.WriteFile(filepath.Join(tmp, "docs", "debug.log"), []byte("log"), 0644)
+		ioutil.WriteFile(filepath.Join(tmp, "docs", ".git", "HEAD"), []byte("ref"), 0644)
+		remote := []RepositoryEntry{
+			{Id: blobId([]byte("same")), Path: "site/same.md", Type: "blob", Mode: "100644"},
+			{Id: blobId([]byte("old content")), Path: "site/changed.md", Type: "blob", Mode: "100644"},
+			{Id: blobId([]byte("gone")), Path: "site/gone.md", Type: "blob", Mode: "100644"},
+			{Id: blobId([]byte("keep")), Path: "site/keep.log", Type: "blob", Mode: "100644"},
+			{Id: "t1", Path: "site/img", Type: "tree", Mode: "040000"},
+		}
+		var h *testrq
+		var tree url.Values
+		missing := false
+		h = thp(func(v url.Values) (interface{}, error, int) {
+			if strings.HasSuffix(h.path, "/repository/tree") {
+				tree = v
+				if missing {
+					return nil, fmt.Errorf(`{"message":"404 Tree Not Found"}`), 404
+				}
+				b, _ := json.Marshal(remote)
+				return b, nil, 200
+			}
+			return []byte(`{"id":"newsha"}`), nil, 201
+		})
+		srv, cl := StubHandler(h)
+		defer srv.Close()
+		opts := &SyncOptions{Path: "site", Exclude: []string{"**/*.log"}}
+		expected := []SyncChange{
+			{Action: CreateAction, Path: "site/build.sh"},
+			{Action: UpdateAction, Path: "site/changed.md"},
+			{Action: DeleteAction, Path: "site/gone.md"},
+		}
+		Convey("a dry run only reports the changes", func() {
+			opts.DryRun = true
+			r, e := cl.SyncDirectory("1", "pages", filepath.Join(tmp, "docs"), "sync", opts)
+			So(e, ShouldBeNil)
+			So(r.Changes, ShouldResemble, expected)
+			So(r.Commit, ShouldBeNil)
+			So(h.method, ShouldEqual, "GET")
+			So(tree.Get("path"), ShouldEqual, "site")
+			So(tree.Get("ref"), ShouldEqual, "pages")
+			So(tree.Get("recursive"), ShouldEqual, "true")
+		})
+		Convey("the differences are committed", func() {
+			opts.DryRun = false
+			r, e := cl.SyncDirectory("1", "pages", filepath.Join(tmp, "docs"), "sync", opts)
+			So(e, ShouldBeNil)
+			So(r.Commit.Id, ShouldEqual, "newsha")
+			So(h.method, ShouldEqual, "POST")
+			So(h.path, ShouldEqual, "/projects/1/repository/commits")
+			var c createCommit
+			json.Unmarshal(h.body, &c)
+			So(c.Branch, ShouldEqual, "pages")
+			// the changes must be computed against the tree of the branch
+			So(tree.Get("ref"), ShouldEqual, c.Branch)
+			So(tree, hasnot, "ref_name")
+			So(c.Message, ShouldEqual, "sync")
+			So(len(c.Actions), ShouldEqual, 3)
+			So(c.Actions[0].Encoding, ShouldEqual, Base64Encoding)
+			So(*c.Actions[0].ExecuteFilemode, ShouldBeTrue)
+			So(c.Actions[1].ExecuteFilemode, ShouldBeNil)
+		})
+		Convey("a missing directory is created", func() {
+			missing = true
+			opts.DryRun = true
+			r, e := cl.SyncDirectory("1", "pages", filepath.Join(tmp, "docs"), "sync", opts)
+			So(e, ShouldBeNil)
+			So(r.Changes, ShouldResemble, []SyncChange{
+				{Action: CreateAction, Path: "site/build.sh"},
+				{Action: CreateAction, Path: "site/changed.md"},
+				{Action: CreateAction, Path: "site/same.md"},
+			})
+			missing = false
+		})
+		Convey("no changes create no commit", func() {
+			opts.Include = []string{"same.md"}
+			r, e := cl.SyncDirectory("1", "pages", filepath.Join(tmp, "docs"), "sync", opts)
+			So(e, ShouldBeNil)
+			So(r.NoChanges(), ShouldBeTrue)
+			So(r.Commit, ShouldBeNil)
+			So(h.method, ShouldEqual, "GET")
+		})
+	})
+}
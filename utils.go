@@ -7,9 +7,11 @@ import (
 	"encoding/pem"
 	"fmt"
 	"net/url"
+	"path"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -63,10 +65,13 @@ func parseLinkHeaders(lnk string) *Pagination {
 	return &p
 }
 
+// expandUrl replaces the placeholders in the given url with the values
+// of the params. The values are path escaped, so a project can also be
+// referenced by its namespace and path, e.g. "group/subgroup/project".
 func expandUrl(u string, params map[string]interface{}) string {
 	if params != nil {
 		for key, val := range params {
-			sval := fmt.Sprintf("%v", val)
+			sval := url.PathEscape(fmt.Sprintf("%v", val))
 			u = strings.Replace(u, key, sval, -1)
 		}
 	}
@@ -109,6 +114,53 @@ func addInt(mp url.Values, key string, val *int) {
 func addBool(mp url.Values, key string, val bool) {
 	mp.Set(key, fmt.Sprintf("%v", val))
 }
+func addTime(mp url.Values, key string, val *time.Time) {
+	if val != nil {
+		mp.Set(key, val.Format(time.RFC3339))
+	}
+}
+func addOptBool(mp url.Values, key string, val *bool) {
+	if val != nil {
+		addBool(mp, key, *val)
+	}
+}
+
+// matchGlob matches the slash separated name against the pattern. Every
+// segment is matched with path.Match, a "**" segment matches any number
+// of segments.
+func matchGlob(pattern, name string) (bool, error) {
+	ok, err := matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+	if err != nil {
+		return false, InvalidParam.Wrap(err)
+	}
+	return ok, nil
+}
+
+func matchSegments(pat, name []string) (bool, error) {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			if len(pat) == 1 {
+				return true, nil
+			}
+			for i := 0; i <= len(name); i++ {
+				ok, err := matchSegments(pat[1:], name[i:])
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(pat[0], name[0])
+		if err != nil || !ok {
+			return false, err
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
 
 // Some crypto helpers, copied from drone
 
@@ -0,0 +1,179 @@
+package gl
+
+import (
+	"net/url"
+)
+
+type VariableType string
+
+const (
+	EnvVariable  = VariableType("env_var")
+	FileVariable = VariableType("file")
+)
+
+const (
+	projectvariables_url = "/projects/:id/variables"
+	projectvariable_url  = "/projects/:id/variables/:key"
+	groupvariables_url   = "/groups/:id/variables"
+	groupvariable_url    = "/groups/:id/variables/:key"
+)
+
+// A CI/CD variable of a project or a group.
+type Variable struct {
+	Key              string       `json:"key,omitempty"`
+	Value            string       `json:"value,omitempty"`
+	VariableType     VariableType `json:"variable_type,omitempty"`
+	Protected        bool         `json:"protected,omitempty"`
+	Masked           bool         `json:"masked,omitempty"`
+	EnvironmentScope string       `json:"environment_scope,omitempty"`
+}
+type Variables []Variable
+
+// VariableOptions contains the optional settings of a variable. Only the
+// non-nil values are sent to gitlab. The environment scope is only
+// supported for project variables.
+type VariableOptions struct {
+	VariableType     *VariableType
+	Protected        *bool
+	Masked           *bool
+	EnvironmentScope *string
+}
+
+func (o *VariableOptions) values() url.Values {
+	vals := make(url.Values)
+	if o != nil {
+		if o.VariableType != nil {
+			vals.Set("variable_type", string(*o.VariableType))
+		}
+		addOptBool(vals, "protected", o.Protected)
+		addOptBool(vals, "masked", o.Masked)
+		addString(vals, "environment_scope", o.EnvironmentScope)
+	}
+	return vals
+}
+
+// the environment scope filter selects one of several variables with
+// the same key
+func scopeFilter(scope *string) url.Values {
+	vals := make(url.Values)
+	addString(vals, "filter[environment_scope]", scope)
+	return vals
+}
+
+func (g *Client) variables(vurl string, id interface{}, pg *Page) (Variables, *Pagination, error) {
+	var r Variables
+	u := expandUrl(vurl, map[string]interface{}{":id": id})
+	pager, e := g.get(u, nil, pg, &r)
+	if e != nil {
+		return nil, nil, e
+	}
+	return r, pager, nil
+}
+
+func (g *Client) allVariables(f fetchFunc) (Variables, error) {
+	var r Variables
+	err := fetchAll(f, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (g *Client) variable(vurl string, id interface{}, key string, scope *string) (*Variable, error) {
+	u := expandUrl(vurl, map[string]interface{}{":id": id, ":key": key})
+	var v Variable
+	_, e := g.get(u, scopeFilter(scope), nil, &v)
+	if e != nil {
+		return nil, e
+	}
+	return &v, nil
+}
+
+func (g *Client) createVariable(vurl string, id interface{}, key, value string, opts *VariableOptions) (*Variable, error) {
+	u := expandUrl(vurl, map[string]interface{}{":id": id})
+	vals := opts.values()
+	vals.Set("key", key)
+	vals.Set("value", value)
+	var v Variable
+	e := g.post(u, vals, &v)
+	if e != nil {
+		return nil, e
+	}
+	return &v, nil
+}
+
+func (g *Client) updateVariable(vurl string, id interface{}, key string, scope *string, value string, opts *VariableOptions) (*Variable, error) {
+	u := expandUrl(vurl, map[string]interface{}{":id": id, ":key": key})
+	vals := opts.values()
+	vals.Set("value", value)
+	addString(vals, "filter[environment_scope]", scope)
+	var v Variable
+	e := g.put(u, vals, &v)
+	if e != nil {
+		return nil, e
+	}
+	return &v, nil
+}
+
+func (g *Client) deleteVariable(vurl string, id interface{}, key string, scope *string) error {
+	u := expandUrl(vurl, map[string]interface{}{":id": id, ":key": key})
+	return g.delete(u, scopeFilter(scope), nil)
+}
+
+func (g *Client) ProjectVariables(id string, pg *Page) (Variables, *Pagination, error) {
+	return g.variables(projectvariables_url, id, pg)
+}
+func (g *Client) AllProjectVariables(id string) (Variables, error) {
+	return g.allVariables(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.ProjectVariables(id, pg)
+	})
+}
+
+// Returns the variable with the given key. If there are several variables
+// with this key, scope selects the environment scope of the variable.
+func (g *Client) ProjectVariable(id string, key string, scope *string) (*Variable, error) {
+	return g.variable(projectvariable_url, id, key, scope)
+}
+func (g *Client) CreateProjectVariable(id string, key, value string, opts *VariableOptions) (*Variable, error) {
+	return g.createVariable(projectvariables_url, id, key, value, opts)
+}
+
+// Updates the variable with the given key. If there are several variables
+// with this key, scope selects the one to update, the EnvironmentScope of
+// the options moves the variable to another scope.
+func (g *Client) UpdateProjectVariable(id string, key string, scope *string, value string, opts *VariableOptions) (*Variable, error) {
+	return g.updateVariable(projectvariable_url, id, key, scope, value, opts)
+}
+func (g *Client) DeleteProjectVariable(id string, key string, scope *string) error {
+	return g.deleteVariable(projectvariable_url, id, key, scope)
+}
+
+// Needs the v4 api.
+func (g *Client) GroupVariables(gid int, pg *Page) (Variables, *Pagination, error) {
+	return g.variables(groupvariables_url, gid, pg)
+}
+func (g *Client) AllGroupVariables(gid int) (Variables, error) {
+	return g.allVariables(func(pg *Page) (interface{}, *Pagination, error) {
+		return g.GroupVariables(gid, pg)
+	})
+}
+
+// Needs the v4 api.
+func (g *Client) GroupVariable(gid int, key string) (*Variable, error) {
+	return g.variable(groupvariable_url, gid, key, nil)
+}
+
+// Needs the v4 api.
+func (g *Client) CreateGroupVariable(gid int, key, value string, opts *VariableOptions) (*Variable, error) {
+	return g.createVariable(groupvariables_url, gid, key, value, opts)
+}
+
+// Needs the v4 api.
+func (g *Client) UpdateGroupVariable(gid int, key, value string, opts *VariableOptions) (*Variable, error) {
+	return g.updateVariable(groupvariable_url, gid, key, nil, value, opts)
+}
+
+// Needs the v4 api.
+func (g *Client) DeleteGroupVariable(gid int, key string) error {
+	return g.deleteVariable(groupvariable_url, gid, key, nil)
+}
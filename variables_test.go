@@ -0,0 +1,84 @@
+package gl
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"net/url"
+	"testing"
+)
+
+func TestVariables(t *testing.T) {
+	Convey("CI/CD variable functions", t, func() {
+		Convey("List all project variables", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return Variables{Variable{}}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.AllProjectVariables("1")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "GET")
+				So(h.path, ShouldEqual, "/projects/1/variables")
+			})
+		})
+		Convey("Create a masked project variable", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Variable{}, nil, 201
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			masked := true
+			vt := FileVariable
+			cl.CreateProjectVariable("1", "TOKEN", "secret", &VariableOptions{Masked: &masked, VariableType: &vt})
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "POST")
+				So(h.path, ShouldEqual, "/projects/1/variables")
+				So(h.get("key"), ShouldEqual, "TOKEN")
+				So(h.get("value"), ShouldEqual, "secret")
+				So(h.get("masked"), ShouldEqual, "true")
+				So(h.get("variable_type"), ShouldEqual, "file")
+				So(h.values, hasnot, "protected", "environment_scope")
+			})
+		})
+		Convey("Update a scoped project variable", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Variable{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			scope := "production"
+			cl.UpdateProjectVariable("1", "TOKEN", &scope, "other", nil)
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "PUT")
+				So(h.path, ShouldEqual, "/projects/1/variables/TOKEN")
+				So(h.get("value"), ShouldEqual, "other")
+				So(h.get("filter[environment_scope]"), ShouldEqual, scope)
+				So(h.values, hasnot, "environment_scope")
+			})
+		})
+		Convey("Move a project variable to another scope", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return &Variable{}, nil, 200
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			from, to := "staging", "production"
+			cl.UpdateProjectVariable("1", "TOKEN", &from, "other", &VariableOptions{EnvironmentScope: &to})
+			Convey("the filter must select the old scope", func() {
+				So(h.get("filter[environment_scope]"), ShouldEqual, from)
+				So(h.get("environment_scope"), ShouldEqual, to)
+			})
+		})
+		Convey("Delete a group variable", func() {
+			h := th(func(v url.Values) (interface{}, error, int) {
+				return nil, nil, 204
+			})
+			srv, cl := StubHandler(h)
+			defer srv.Close()
+			cl.DeleteGroupVariable(3, "TOKEN")
+			Convey("check if the request was correct", func() {
+				So(h.method, ShouldEqual, "DELETE")
+				So(h.path, ShouldEqual, "/groups/3/variables/TOKEN")
+			})
+		})
+	})
+}